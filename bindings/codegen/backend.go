@@ -0,0 +1,82 @@
+package codegen
+
+import (
+	"io"
+
+	"github.com/panyam/relay/bindings"
+)
+
+/**
+ * A Backend emits one target language/format's representation of a service
+ * definition: rest.Generator (HTTP+JSON client), grpc.Generator
+ * (Protobuf/gRPC) and openapi.Generator (OpenAPI 3) each implement this so
+ * the same bindings.RecordTypeData / bindings.FunctionTypeData produced by
+ * the IDL can drive any of them.
+ */
+type Backend interface {
+	/**
+	 * Emits whatever top level scaffolding a service definition needs, eg a
+	 * client class, a service stub, a `service` block in a .proto file.
+	 */
+	EmitService(output io.Writer, pkgName string, serviceName string, serviceType *bindings.RecordTypeData) error
+
+	/**
+	 * Emits a single operation (method/rpc/path) on the service.
+	 */
+	EmitOperation(output io.Writer, opName string, opType *bindings.FunctionTypeData) error
+
+	/**
+	 * Emits the serializer/schema reference for a single type.
+	 */
+	EmitTypeSerializer(output io.Writer, t *bindings.Type) error
+
+	/**
+	 * Closes whatever EmitService opened, eg the grpc backend's `service { `
+	 * block.  Called once after the last EmitOperation call.  Backends with
+	 * nothing to close (rest, openapi) are a no-op.
+	 */
+	EmitServiceEnd(output io.Writer) error
+}
+
+/**
+ * TypeVisitor replaces the old package-level WriterMethodForType switch in
+ * rest.Generator so each Backend can supply its own naming/encoding for
+ * every bindings.Type shape, including the tuple and function types the
+ * original WriterMethodForType panicked on.
+ */
+type TypeVisitor interface {
+	VisitPrimitive(name string) string
+	VisitAlias(alias *bindings.AliasTypeData) string
+	VisitReference(ref *bindings.ReferenceTypeData) string
+	VisitRecord(rec *bindings.RecordTypeData) string
+	VisitMap(m *bindings.MapTypeData) string
+	VisitList(l *bindings.ListTypeData) string
+	VisitTuple(t *bindings.TupleTypeData) string
+	VisitFunction(f *bindings.FunctionTypeData) string
+}
+
+/**
+ * Dispatches t's TypeData to the matching TypeVisitor method, mirroring the
+ * switch the original WriterMethodForType used.
+ */
+func VisitType(v TypeVisitor, t *bindings.Type) string {
+	switch typeData := t.TypeData.(type) {
+	case string:
+		return v.VisitPrimitive(typeData)
+	case *bindings.AliasTypeData:
+		return v.VisitAlias(typeData)
+	case *bindings.ReferenceTypeData:
+		return v.VisitReference(typeData)
+	case *bindings.FunctionTypeData:
+		return v.VisitFunction(typeData)
+	case *bindings.TupleTypeData:
+		return v.VisitTuple(typeData)
+	case *bindings.RecordTypeData:
+		return v.VisitRecord(typeData)
+	case *bindings.MapTypeData:
+		return v.VisitMap(typeData)
+	case *bindings.ListTypeData:
+		return v.VisitList(typeData)
+	}
+	return "Unknown"
+}
@@ -0,0 +1,186 @@
+package grpc
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/panyam/relay/bindings"
+	"github.com/panyam/relay/bindings/codegen"
+)
+
+/**
+ * Emits a .proto file for a service definition, the gRPC/Protobuf
+ * counterpart of rest.Generator's hand-written HTTP client.  Only the .proto
+ * text is emitted; no Go server or client stub code is generated.
+ */
+type Generator struct {
+	TypeSystem  bindings.ITypeSystem
+	Package     string
+	ServiceName string
+	ServiceType *bindings.RecordTypeData
+
+	messageNum int
+	emitted    map[string]bool // names of message types already written, so they're emitted exactly once
+}
+
+func NewGenerator(typeSys bindings.ITypeSystem) *Generator {
+	return &Generator{TypeSystem: typeSys}
+}
+
+/**
+ * Opens the `service { ... }` block.  The rpc lines inside it are written
+ * afterwards via EmitOperation, and EmitServiceEnd closes the block.
+ */
+func (g *Generator) EmitService(output io.Writer, pkgName string, serviceName string, serviceType *bindings.RecordTypeData) error {
+	g.Package = pkgName
+	g.ServiceName = serviceName
+	g.ServiceType = serviceType
+	fmt.Fprintf(output, "syntax = \"proto3\";\n\npackage %s;\n\nservice %s {\n", pkgName, serviceName)
+	return nil
+}
+
+/**
+ * Emits a single `rpc Foo (FooRequest) returns (FooResponse);` line.  The
+ * FooRequest/FooResponse messages are referenced by name only: neither this
+ * method nor EmitTypeSerializer emits them, and opType's input/output types
+ * are not used.
+ */
+func (g *Generator) EmitOperation(output io.Writer, opName string, opType *bindings.FunctionTypeData) error {
+	fmt.Fprintf(output, "  rpc %s (%sRequest) returns (%sResponse);\n", opName, opName, opName)
+	return nil
+}
+
+/**
+ * Closes the `service { ... }` block EmitService opened.  Callers emit this
+ * once after the last EmitOperation call for the service.
+ */
+func (g *Generator) EmitServiceEnd(output io.Writer) error {
+	_, err := fmt.Fprintf(output, "}\n")
+	return err
+}
+
+/**
+ * Emits a `message` block for t, naming and numbering its fields by the
+ * grpcTypeVisitor below, followed by one block for each record type its
+ * fields reach that has not been emitted yet.
+ */
+func (g *Generator) EmitTypeSerializer(output io.Writer, t *bindings.Type) error {
+	visitor := &grpcTypeVisitor{gen: g, output: output}
+	codegen.VisitType(visitor, t)
+	return nil
+}
+
+/**
+ * grpcTypeVisitor is the Protobuf backend's codegen.TypeVisitor: it both
+ * names a type (for use as a field/message type) and, for RecordTypeData,
+ * emits the full `message` block as a side effect the first time it is
+ * visited.
+ */
+type grpcTypeVisitor struct {
+	gen    *Generator
+	output io.Writer
+}
+
+// grpcFieldVisitor wraps grpcTypeVisitor for use while walking a message's
+// fields: it behaves identically except a nested record type is only
+// referenced by name here -- its `message` block is collected into nested
+// and flushed by the caller as its own top-level message, once the
+// parent's is closed.
+type grpcFieldVisitor struct {
+	*grpcTypeVisitor
+	nested *[]*bindings.RecordTypeData
+}
+
+func (v *grpcFieldVisitor) VisitRecord(rec *bindings.RecordTypeData) string {
+	if !v.gen.emitted[rec.Name] {
+		*v.nested = append(*v.nested, rec)
+	}
+	return rec.Name
+}
+
+func (v *grpcFieldVisitor) VisitAlias(alias *bindings.AliasTypeData) string {
+	return codegen.VisitType(v, alias.AliasFor)
+}
+
+func (v *grpcFieldVisitor) VisitReference(ref *bindings.ReferenceTypeData) string {
+	return codegen.VisitType(v, ref.TargetType)
+}
+
+func (v *grpcFieldVisitor) VisitMap(m *bindings.MapTypeData) string {
+	return fmt.Sprintf("map<%s, %s>", codegen.VisitType(v, m.KeyType), codegen.VisitType(v, m.ValueType))
+}
+
+func (v *grpcFieldVisitor) VisitList(l *bindings.ListTypeData) string {
+	return "repeated " + codegen.VisitType(v, l.ElementType)
+}
+
+func (v *grpcTypeVisitor) VisitPrimitive(name string) string {
+	switch name {
+	case "int":
+		return "int64"
+	case "float":
+		return "double"
+	case "bool":
+		return "bool"
+	case "string":
+		return "string"
+	default:
+		return name
+	}
+}
+
+func (v *grpcTypeVisitor) VisitAlias(alias *bindings.AliasTypeData) string {
+	return codegen.VisitType(v, alias.AliasFor)
+}
+
+func (v *grpcTypeVisitor) VisitReference(ref *bindings.ReferenceTypeData) string {
+	return codegen.VisitType(v, ref.TargetType)
+}
+
+func (v *grpcTypeVisitor) VisitRecord(rec *bindings.RecordTypeData) string {
+	if v.gen.emitted == nil {
+		v.gen.emitted = map[string]bool{}
+	}
+	if v.gen.emitted[rec.Name] {
+		return rec.Name
+	}
+	v.gen.emitted[rec.Name] = true
+
+	var nested []*bindings.RecordTypeData
+	fieldVisitor := &grpcFieldVisitor{grpcTypeVisitor: v, nested: &nested}
+	fmt.Fprintf(v.output, "\nmessage %s {\n", rec.Name)
+	for index, field := range rec.Fields {
+		fieldType := codegen.VisitType(fieldVisitor, field.FieldType)
+		fmt.Fprintf(v.output, "  %s %s = %d;\n", fieldType, field.Name, index+1)
+	}
+	fmt.Fprintf(v.output, "}\n")
+
+	for _, n := range nested {
+		v.VisitRecord(n)
+	}
+	return rec.Name
+}
+
+func (v *grpcTypeVisitor) VisitMap(m *bindings.MapTypeData) string {
+	return fmt.Sprintf("map<%s, %s>", codegen.VisitType(v, m.KeyType), codegen.VisitType(v, m.ValueType))
+}
+
+func (v *grpcTypeVisitor) VisitList(l *bindings.ListTypeData) string {
+	return "repeated " + codegen.VisitType(v, l.ElementType)
+}
+
+func (v *grpcTypeVisitor) VisitTuple(t *bindings.TupleTypeData) string {
+	// Protobuf has no tuple type; encode it opaquely rather than failing
+	// codegen outright.
+	fmt.Fprintf(v.output, "  // tuple type: no Protobuf representation, serialized as opaque bytes\n")
+	return "bytes"
+}
+
+func (v *grpcTypeVisitor) VisitFunction(f *bindings.FunctionTypeData) string {
+	// Functions aren't serializable at all; encode them opaquely rather
+	// than failing codegen outright.
+	fmt.Fprintf(v.output, "  // function type: no Protobuf representation, serialized as opaque bytes\n")
+	return "bytes"
+}
+
+var _ codegen.Backend = (*Generator)(nil)
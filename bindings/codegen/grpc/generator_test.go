@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/panyam/relay/bindings"
+)
+
+func primitiveType(name string) *bindings.Type {
+	return &bindings.Type{TypeData: name}
+}
+
+func recordType(name string, fields ...*bindings.Field) *bindings.Type {
+	return &bindings.Type{TypeData: &bindings.RecordTypeData{Name: name, Fields: fields}}
+}
+
+func field(name string, t *bindings.Type) *bindings.Field {
+	return &bindings.Field{Name: name, FieldType: t}
+}
+
+func TestEmitTypeSerializerFlatRecord(t *testing.T) {
+	gen := NewGenerator(nil)
+	out := &bytes.Buffer{}
+	userType := recordType("User", field("Id", primitiveType("string")), field("Active", primitiveType("bool")))
+
+	if err := gen.EmitTypeSerializer(out, userType); err != nil {
+		t.Fatalf("EmitTypeSerializer returned error: %v", err)
+	}
+
+	got := out.String()
+	if !strings.Contains(got, "message User {") {
+		t.Fatalf("expected a User message block, got:\n%s", got)
+	}
+	if !strings.Contains(got, "string Id = 1;") || !strings.Contains(got, "bool Active = 2;") {
+		t.Fatalf("expected fields to be numbered in order, got:\n%s", got)
+	}
+}
+
+func TestEmitTypeSerializerNestedRecordEmittedOnceAsSeparateMessage(t *testing.T) {
+	gen := NewGenerator(nil)
+	out := &bytes.Buffer{}
+	addressType := recordType("Address", field("City", primitiveType("string")))
+	userType := recordType("User",
+		field("Id", primitiveType("string")),
+		field("HomeAddress", addressType),
+		field("WorkAddress", addressType),
+	)
+
+	if err := gen.EmitTypeSerializer(out, userType); err != nil {
+		t.Fatalf("EmitTypeSerializer returned error: %v", err)
+	}
+
+	got := out.String()
+	if strings.Count(got, "message Address {") != 1 {
+		t.Fatalf("expected Address message to be emitted exactly once, got:\n%s", got)
+	}
+	if strings.Count(got, "message User {") != 1 {
+		t.Fatalf("expected User message to be emitted exactly once, got:\n%s", got)
+	}
+	// The User message's field list should reference Address by name, not
+	// contain its body inline.
+	userBlock := got[strings.Index(got, "message User {"):]
+	userBlock = userBlock[:strings.Index(userBlock, "}")]
+	if strings.Contains(userBlock, "City") {
+		t.Fatalf("expected User's field list to reference Address by name only, got:\n%s", userBlock)
+	}
+	if !strings.Contains(userBlock, "Address HomeAddress = 2;") || !strings.Contains(userBlock, "Address WorkAddress = 3;") {
+		t.Fatalf("expected both fields to reference Address by type name, got:\n%s", userBlock)
+	}
+}
+
+func TestVisitTupleAndFunctionDoNotPanic(t *testing.T) {
+	gen := NewGenerator(nil)
+	out := &bytes.Buffer{}
+	recType := recordType("Weird",
+		field("Pair", &bindings.Type{TypeData: &bindings.TupleTypeData{}}),
+		field("Callback", &bindings.Type{TypeData: &bindings.FunctionTypeData{}}),
+	)
+
+	if err := gen.EmitTypeSerializer(out, recType); err != nil {
+		t.Fatalf("EmitTypeSerializer returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "bytes Pair") || !strings.Contains(out.String(), "bytes Callback") {
+		t.Fatalf("expected tuple/function fields to degrade to bytes, got:\n%s", out.String())
+	}
+}
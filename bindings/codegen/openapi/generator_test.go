@@ -0,0 +1,185 @@
+package openapi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/panyam/relay/bindings"
+)
+
+func primitiveType(name string) *bindings.Type {
+	return &bindings.Type{TypeData: name}
+}
+
+func recordType(name string, fields ...*bindings.Field) *bindings.Type {
+	return &bindings.Type{TypeData: &bindings.RecordTypeData{Name: name, Fields: fields}}
+}
+
+func field(name string, t *bindings.Type) *bindings.Field {
+	return &bindings.Field{Name: name, FieldType: t}
+}
+
+func TestEmitTypeSerializerFlatRecord(t *testing.T) {
+	gen := NewGenerator(nil)
+	out := &bytes.Buffer{}
+	userType := recordType("User", field("Id", primitiveType("string")), field("Active", primitiveType("bool")))
+
+	if err := gen.EmitTypeSerializer(out, userType); err != nil {
+		t.Fatalf("EmitTypeSerializer returned error: %v", err)
+	}
+
+	got := out.String()
+	if !strings.Contains(got, "User:") {
+		t.Fatalf("expected a User schema entry, got:\n%s", got)
+	}
+	if !strings.Contains(got, "type: boolean") {
+		t.Fatalf("expected bool field to map to boolean, got:\n%s", got)
+	}
+	if strings.Contains(got, "type: bool\n") {
+		t.Fatalf("bool must not be emitted as the invalid OpenAPI type %q, got:\n%s", "bool", got)
+	}
+}
+
+func TestEmitTypeSerializerNestedRecordEmittedOnceAsSeparateSchema(t *testing.T) {
+	gen := NewGenerator(nil)
+	out := &bytes.Buffer{}
+	addressType := recordType("Address", field("City", primitiveType("string")))
+	userType := recordType("User",
+		field("Id", primitiveType("string")),
+		field("HomeAddress", addressType),
+		field("WorkAddress", addressType),
+	)
+
+	if err := gen.EmitTypeSerializer(out, userType); err != nil {
+		t.Fatalf("EmitTypeSerializer returned error: %v", err)
+	}
+
+	got := out.String()
+	// Schema keys sit at a 4-space indent; property keys like "HomeAddress:"
+	// are deeper and must not be counted.
+	if strings.Count(got, "    Address:\n") != 1 {
+		t.Fatalf("expected Address schema to be emitted exactly once, got:\n%s", got)
+	}
+	if strings.Count(got, "User:\n") != 1 {
+		t.Fatalf("expected User schema to be emitted exactly once, got:\n%s", got)
+	}
+	// The User schema's properties end where the flushed Address schema begins.
+	userBlock := got[strings.Index(got, "User:\n"):]
+	userBlock = userBlock[:strings.Index(userBlock, "    Address:\n")]
+	if strings.Contains(userBlock, "City") {
+		t.Fatalf("expected User's properties to reference Address by name only, got:\n%s", userBlock)
+	}
+	if !strings.Contains(userBlock, "$ref: '#/components/schemas/Address'") {
+		t.Fatalf("expected a record-typed field to be emitted as a $ref, got:\n%s", userBlock)
+	}
+	if strings.Contains(userBlock, "type: Address") {
+		t.Fatalf("a record-typed field must not be emitted as the invalid OpenAPI type %q, got:\n%s", "Address", userBlock)
+	}
+}
+
+func TestEmitTypeSerializerListAndMapOfRecordEmitItemsAndCollectSchema(t *testing.T) {
+	gen := NewGenerator(nil)
+	out := &bytes.Buffer{}
+	addressType := recordType("Address", field("City", primitiveType("string")))
+	userType := recordType("User",
+		field("Id", primitiveType("string")),
+		field("Addresses", &bindings.Type{TypeData: &bindings.ListTypeData{ElementType: addressType}}),
+		field("AddressesByLabel", &bindings.Type{TypeData: &bindings.MapTypeData{KeyType: primitiveType("string"), ValueType: addressType}}),
+	)
+
+	if err := gen.EmitTypeSerializer(out, userType); err != nil {
+		t.Fatalf("EmitTypeSerializer returned error: %v", err)
+	}
+
+	got := out.String()
+	if strings.Count(got, "Address:\n") != 1 {
+		t.Fatalf("expected Address schema to be emitted exactly once, got:\n%s", got)
+	}
+	if !strings.Contains(got, "type: array\n          items:\n            $ref: '#/components/schemas/Address'") {
+		t.Fatalf("expected a list-of-record field to emit items: $ref, got:\n%s", got)
+	}
+	if !strings.Contains(got, "type: object\n          additionalProperties:\n            $ref: '#/components/schemas/Address'") {
+		t.Fatalf("expected a map-of-record field to emit additionalProperties: $ref, got:\n%s", got)
+	}
+}
+
+func TestEmitOperationSkipsUnboundOpsAndUsesBindingMethodAndPath(t *testing.T) {
+	gen := NewGenerator(nil)
+	gen.Bindings = map[string]*HttpBinding{
+		"GetUser": {Method: "GET", Path: "/users/{id}"},
+	}
+	opType := &bindings.FunctionTypeData{}
+
+	out := &bytes.Buffer{}
+	if err := gen.EmitOperation(out, "GetUser", opType); err != nil {
+		t.Fatalf("EmitOperation returned error: %v", err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "/users/{id}:") || !strings.Contains(got, "get:") {
+		t.Fatalf("expected path and method from the binding, got:\n%s", got)
+	}
+
+	if strings.Contains(got, "requestBody:") {
+		t.Fatalf("expected a GET operation to have no requestBody, got:\n%s", got)
+	}
+
+	out.Reset()
+	if err := gen.EmitOperation(out, "Unbound", opType); err != nil {
+		t.Fatalf("EmitOperation returned error: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected an operation with no binding to be skipped, got:\n%s", out.String())
+	}
+}
+
+func TestEmitOperationEmitsRequestBodyForSingleInputNonGetOps(t *testing.T) {
+	gen := NewGenerator(nil)
+	gen.Bindings = map[string]*HttpBinding{
+		"CreateUser": {Method: "POST", Path: "/users"},
+	}
+	opType := &bindings.FunctionTypeData{InputTypes: []*bindings.Type{recordType("User", field("Id", primitiveType("string")))}}
+
+	out := &bytes.Buffer{}
+	if err := gen.EmitOperation(out, "CreateUser", opType); err != nil {
+		t.Fatalf("EmitOperation returned error: %v", err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "requestBody:") || !strings.Contains(got, "$ref: '#/components/schemas/User'") {
+		t.Fatalf("expected a single-input POST operation to emit a requestBody referencing User, got:\n%s", got)
+	}
+}
+
+func TestEmitOperationEmitsRequestBodyForMultiInputNonGetOps(t *testing.T) {
+	// Every real service method takes a leading `caller *User` (see
+	// services/interface.go), so this is the common shape, e.g.
+	// CreateMessage(caller, message) -- not an edge case.
+	gen := NewGenerator(nil)
+	gen.Bindings = map[string]*HttpBinding{
+		"CreateMessage": {Method: "POST", Path: "/messages"},
+	}
+	opType := &bindings.FunctionTypeData{InputTypes: []*bindings.Type{
+		recordType("User", field("Id", primitiveType("string"))),
+		recordType("Message", field("Id", primitiveType("string"))),
+	}}
+
+	out := &bytes.Buffer{}
+	if err := gen.EmitOperation(out, "CreateMessage", opType); err != nil {
+		t.Fatalf("EmitOperation returned error: %v", err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "requestBody:") || !strings.Contains(got, "type: array") {
+		t.Fatalf("expected a multi-input POST operation to emit a requestBody array schema, got:\n%s", got)
+	}
+}
+
+func TestVisitTupleAndFunctionDoNotPanic(t *testing.T) {
+	gen := NewGenerator(nil)
+	v := &openapiTypeVisitor{gen: gen}
+	if name := v.VisitTuple(&bindings.TupleTypeData{}); name == "" {
+		t.Fatalf("expected VisitTuple to return a placeholder type name")
+	}
+	if name := v.VisitFunction(&bindings.FunctionTypeData{}); name == "" {
+		t.Fatalf("expected VisitFunction to return a placeholder type name")
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"errors"
 	"fmt"
 	authcore "github.com/panyam/relay/services/auth/core"
+	. "github.com/panyam/relay/services/commands/core"
+	importercore "github.com/panyam/relay/services/importer/core"
 	msgcore "github.com/panyam/relay/services/msg/core"
+	presencecore "github.com/panyam/relay/services/presence/core"
 	"github.com/panyam/relay/utils"
 	"io"
 	"log"
@@ -183,3 +186,174 @@ func (client *ApiClient) GetChannels(team *msgcore.Team,
 	}
 	return nil, nil, nil // msgcore.ChannelFromDict(data)
 }
+
+// Execute a slash command in a channel.
+//
+// **Endpoints:** POST /commands/execute
+//
+// **Auth Required:** YES and user must be a participant in the channel.
+//
+// **Parameters:**
+//
+// - channel: The channel the command is being run in.
+// - body: The raw message body, including the leading "/trigger".
+//
+// **Return:**
+//
+// HTTP Status 200 on success along with the resulting message (which may be
+// ephemeral, ie visible only to the caller).  HTTP 404 if the trigger is not
+// registered.
+func (client *ApiClient) ExecuteCommand(channel *msgcore.Channel, body string) (*msgcore.Message, error) {
+	return nil, nil
+}
+
+// List the slash commands available for auto-complete.
+//
+// **Endpoints:** GET /commands/
+//
+// **Auth Required:** YES
+//
+// **Parameters:**
+//		team:	Team to list team-scoped commands for.
+//		channel: Channel to list channel-scoped commands for, in addition to
+//				 the team's commands.
+//
+// **Return:**
+//
+// HTTP Status 200 on success along with the list of commands and their
+// auto-complete metadata available to the caller in that scope.
+func (client *ApiClient) ListCommands(team *msgcore.Team, channel *msgcore.Channel) ([]*CommandMetadata, error) {
+	return nil, nil
+}
+
+// Assigns a role to a user on a channel.
+//
+// **Endpoints:** POST /channels/{id}/assign
+//
+// **Auth Required:** YES and caller must hold "admin" on the channel.
+//
+// **Parameters:**
+//
+// - channel: The channel the role is being granted on.
+// - user: The user being granted the role.
+// - role: One of "owner", "admin", "member", "viewer".
+//
+// **Return:**
+//
+// HTTP Status 200 on success.
+func (client *ApiClient) AssignMember(channel *msgcore.Channel, user *msgcore.User, role string) error {
+	return nil
+}
+
+// Revokes whatever role a user holds on a channel.
+//
+// **Endpoints:** POST /channels/{id}/unassign
+//
+// **Auth Required:** YES and caller must hold "admin" on the channel.
+//
+// **Parameters:**
+//
+// - channel: The channel the role is being revoked on.
+// - user: The user being unassigned.
+//
+// **Return:**
+//
+// HTTP Status 200 on success.
+func (client *ApiClient) UnassignMember(channel *msgcore.Channel, user *msgcore.User) error {
+	return nil
+}
+
+// Sets the caller's live status.
+//
+// **Endpoints:** POST /users/status
+//
+// **Auth Required:** YES
+//
+// **Parameters:**
+//
+// - status: One of "online", "away", "dnd", "offline".
+//
+// **Return:**
+//
+// HTTP Status 200 on success.
+func (client *ApiClient) SetStatus(status presencecore.Status) error {
+	return nil
+}
+
+// Enables or disables the caller's away-message auto-responder.
+//
+// **Endpoints:** POST /users/autoresponder
+//
+// **Auth Required:** YES
+//
+// **Parameters:**
+//
+// - active: Whether the auto-responder should reply to new DMs.
+// - message: The body of the auto-reply while active.
+//
+// **Return:**
+//
+// HTTP Status 200 on success.
+func (client *ApiClient) SetAutoResponder(active bool, message string) error {
+	return nil
+}
+
+// Gets a user's current status and auto-responder configuration.
+//
+// **Endpoints:** GET /users/{id}/status
+//
+// **Auth Required:** YES
+//
+// **Return:**
+//
+// HTTP Status 200 on success along with the user's UserPresence.
+func (client *ApiClient) GetStatus(user *msgcore.User) (*presencecore.UserPresence, error) {
+	return nil, nil
+}
+
+// Uploads a Slack/Mattermost-style archive (users.json, teams.json,
+// channels.json, messages/<channel>.jsonl) for import.
+//
+// **Endpoints:** POST /admin/import (multipart upload)
+//
+// **Auth Required:** YES and caller must hold "admin" on the target org.
+//
+// **Parameters:**
+//
+// - path: Local path to the zip archive to upload.
+// - dryRun: If true, the server reports diffs without writing anything.
+//
+// **Return:**
+//
+// HTTP Status 200 on success along with the id of the import job; poll its
+// progress with GetImportStatus.
+func (client *ApiClient) ImportArchive(path string, dryRun bool) (*importercore.ImportJob, error) {
+	return nil, nil
+}
+
+// Polls the progress of a previously started import job.
+//
+// **Endpoints:** GET /admin/import/{jobId}
+//
+// **Auth Required:** YES, same as ImportArchive.
+//
+// **Return:**
+//
+// HTTP Status 200 on success along with the job's current ImportJob.
+func (client *ApiClient) GetImportStatus(jobId string) (*importercore.ImportJob, error) {
+	return nil, nil
+}
+
+// Streams a Slack/Mattermost-style archive for org to out, honoring the
+// authorization layer so only channels the caller can view are included.
+//
+// **Endpoints:** GET /admin/export?org={org}
+//
+// **Auth Required:** YES
+//
+// **Return:**
+//
+// HTTP Status 200 on success with the archive streamed as the response body.
+func (client *ApiClient) ExportOrg(org string, out io.Writer) error {
+	return nil
+}
@@ -0,0 +1,138 @@
+package goclient
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	hubcore "github.com/panyam/relay/services/hub/core"
+
+	"github.com/gorilla/websocket"
+)
+
+// How long to wait before the first reconnect attempt after an unexpected
+// close, and the ceiling that attempt-over-attempt backoff is capped at.
+const (
+	ReconnectDelay    = 2 * time.Second
+	MaxReconnectDelay = 30 * time.Second
+)
+
+/**
+ * WebsocketClient maintains a single live connection to the /ws endpoint and
+ * replays the auth token on every (re)connect so the server can re-run
+ * IHubService.Subscribe without the caller having to re-authenticate by
+ * hand.
+ */
+type WebsocketClient struct {
+	Url           string
+	Authenticator Authenticator
+	OnEvent       func(event *hubcore.Event)
+
+	mu     sync.Mutex
+	conn   *websocket.Conn
+	closed bool
+}
+
+func NewWebsocketClient(url string, auth Authenticator) *WebsocketClient {
+	return &WebsocketClient{Url: url, Authenticator: auth}
+}
+
+/**
+ * Connects (or reconnects) to the hub endpoint.  On an unexpected close the
+ * client waits ReconnectDelay and connects again, replaying the auth token,
+ * backing off on repeated failures, until Close is called.
+ */
+func (client *WebsocketClient) Connect() error {
+	client.mu.Lock()
+	client.closed = false
+	client.mu.Unlock()
+	return client.connectAndListen()
+}
+
+func (client *WebsocketClient) isClosed() bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.closed
+}
+
+func (client *WebsocketClient) setConn(conn *websocket.Conn) {
+	client.mu.Lock()
+	client.conn = conn
+	client.mu.Unlock()
+}
+
+func (client *WebsocketClient) connectAndListen() error {
+	var header http.Header
+	if client.Authenticator != nil {
+		// Reuse AuthenticateRequest so the handshake replays the exact same
+		// auth token an ApiClient request would, eg a bearer header or
+		// signed cookie, without the hub needing a second auth code path.
+		dummy, _ := http.NewRequest("GET", client.Url, nil)
+		client.Authenticator.AuthenticateRequest(dummy)
+		header = dummy.Header
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(client.Url, header)
+	if err != nil {
+		return err
+	}
+	client.setConn(conn)
+
+	go client.readLoop(conn)
+	return nil
+}
+
+func (client *WebsocketClient) readLoop(conn *websocket.Conn) {
+	for {
+		var event hubcore.Event
+		if err := conn.ReadJSON(&event); err != nil {
+			conn.Close()
+			if client.isClosed() {
+				return
+			}
+			log.Println("websocket read error, reconnecting: ", err)
+			client.reconnectWithBackoff()
+			return
+		}
+		if client.OnEvent != nil {
+			client.OnEvent(&event)
+		}
+	}
+}
+
+/**
+ * Keeps retrying connectAndListen, doubling the delay between attempts up
+ * to MaxReconnectDelay, until one succeeds or Close is called.
+ */
+func (client *WebsocketClient) reconnectWithBackoff() {
+	delay := ReconnectDelay
+	for !client.isClosed() {
+		time.Sleep(delay)
+		if client.isClosed() {
+			return
+		}
+		if err := client.connectAndListen(); err == nil {
+			return
+		} else {
+			log.Println("websocket reconnect failed, retrying: ", err)
+		}
+		if delay *= 2; delay > MaxReconnectDelay {
+			delay = MaxReconnectDelay
+		}
+	}
+}
+
+/**
+ * Closes the connection and stops any further reconnect attempts.
+ */
+func (client *WebsocketClient) Close() error {
+	client.mu.Lock()
+	client.closed = true
+	conn := client.conn
+	client.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
@@ -0,0 +1,86 @@
+package authz
+
+import (
+	"fmt"
+	"strconv"
+
+	. "github.com/panyam/backbone/models"
+	. "github.com/panyam/relay/services/authz/core"
+)
+
+/**
+ * PolicyEngine is the shape an external authorization service (eg a
+ * Zanzibar-style relationship graph) needs to implement so Adapter can stand
+ * in for the in-memory services/authz/memory.AuthzService without the rest
+ * of the codebase knowing the difference.  Every method deals in plain
+ * strings so the engine's own client library doesn't need to know about
+ * backbone/models.
+ */
+type PolicyEngine interface {
+	Check(subjectId string, permission string, objectType string, objectId string) (bool, error)
+	ListObjects(subjectId string, permission string, objectType string) ([]string, error)
+	Write(subjectId string, objectType string, objectId string, role string) error
+	Delete(subjectId string, objectType string, objectId string) error
+}
+
+/**
+ * Adapter implements IAuthzService by delegating every call to a
+ * PolicyEngine, translating the typed services/authz/core values to and from
+ * the plain strings the engine deals in.
+ */
+type Adapter struct {
+	Engine PolicyEngine
+}
+
+func NewAdapter(engine PolicyEngine) *Adapter {
+	return &Adapter{Engine: engine}
+}
+
+func (a *Adapter) Authorize(subject *User, permission Permission, object *Object) (bool, error) {
+	id, err := userId(subject)
+	if err != nil {
+		return false, err
+	}
+	return a.Engine.Check(id, string(permission), string(object.Type), object.Id)
+}
+
+func (a *Adapter) ListAllObjects(subject *User, permission Permission, objectType ObjectType) ([]string, error) {
+	id, err := userId(subject)
+	if err != nil {
+		return nil, err
+	}
+	return a.Engine.ListObjects(id, string(permission), string(objectType))
+}
+
+func (a *Adapter) AssignRole(subject *User, object *Object, role Role) error {
+	id, err := userId(subject)
+	if err != nil {
+		return err
+	}
+	return a.Engine.Write(id, string(object.Type), object.Id, string(role))
+}
+
+func (a *Adapter) UnassignRole(subject *User, object *Object) error {
+	id, err := userId(subject)
+	if err != nil {
+		return err
+	}
+	return a.Engine.Delete(id, string(object.Type), object.Id)
+}
+
+// userId stringifies subject.Id for the PolicyEngine's string-typed API. An
+// unrecognized id type returns an error rather than "" -- a PolicyEngine
+// given an empty subject id could mistake it for an anonymous/wildcard
+// subject and allow something it shouldn't.
+func userId(subject *User) (string, error) {
+	switch id := interface{}(subject.Id).(type) {
+	case string:
+		return id, nil
+	case int64:
+		return strconv.FormatInt(id, 10), nil
+	case int:
+		return strconv.Itoa(id), nil
+	default:
+		return "", fmt.Errorf("authz: unsupported subject id type %T", subject.Id)
+	}
+}
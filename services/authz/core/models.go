@@ -0,0 +1,51 @@
+package core
+
+/**
+ * The relation a subject holds over an object.  Roles are ordered loosely by
+ * privilege (owner > admin > member > viewer) but IAuthzService never
+ * assumes that ordering itself -- each permission check is against an
+ * explicit Permission, not a role comparison.
+ */
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+	RoleViewer Role = "viewer"
+)
+
+/**
+ * A permission being checked for, eg when deciding whether a user may join
+ * a channel or delete a team.
+ */
+type Permission string
+
+const (
+	PermissionView   Permission = "view"
+	PermissionJoin   Permission = "join"
+	PermissionInvite Permission = "invite"
+	PermissionAdmin  Permission = "admin"
+)
+
+/**
+ * The kind of object a relation tuple is scoped to.
+ */
+type ObjectType string
+
+const (
+	ObjectTypeTeam    ObjectType = "team"
+	ObjectTypeChannel ObjectType = "channel"
+	ObjectTypeMessage ObjectType = "message"
+)
+
+/**
+ * An object reference, eg {Type: ObjectTypeChannel, Id: "123"}.  Kept as a
+ * plain id pair (rather than the live *Team/*Channel/*Message pointer)
+ * because the relation model and any external policy engine only ever need
+ * to reason about identity, not the full object.
+ */
+type Object struct {
+	Type ObjectType
+	Id   string
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"sync"
+
+	. "github.com/panyam/backbone/models"
+	. "github.com/panyam/relay/services/authz/core"
+)
+
+// Which permissions each role satisfies, from least to most privileged.
+// A role grants every permission of the roles below it, eg RoleAdmin can
+// also PermissionView and PermissionJoin.
+var rolePermissions = map[Role][]Permission{
+	RoleViewer: {PermissionView},
+	RoleMember: {PermissionView, PermissionJoin},
+	RoleAdmin:  {PermissionView, PermissionJoin, PermissionInvite, PermissionAdmin},
+	RoleOwner:  {PermissionView, PermissionJoin, PermissionInvite, PermissionAdmin},
+}
+
+func permitted(role Role, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * An in-memory IAuthzService backed by a simple (subject, object) -> role
+ * relation tuple map.  Good enough for tests and a single process backend;
+ * production deployments are expected to swap this for authz.Adapter wired
+ * up to a real policy engine.
+ */
+// tupleKey identifies one (subject, object) relation tuple.  A struct key
+// keeps subject/object ids exact regardless of what characters they
+// contain, unlike a "%v/%s/%s"-joined string which a "/" inside any id
+// would corrupt.
+type tupleKey struct {
+	SubjectId  interface{}
+	ObjectType ObjectType
+	ObjectId   string
+}
+
+type AuthzService struct {
+	mutex  sync.RWMutex
+	tuples map[tupleKey]Role
+}
+
+func NewAuthzService() *AuthzService {
+	return &AuthzService{tuples: make(map[tupleKey]Role)}
+}
+
+func newTupleKey(subject *User, object *Object) tupleKey {
+	return tupleKey{SubjectId: subject.Id, ObjectType: object.Type, ObjectId: object.Id}
+}
+
+func (svc *AuthzService) Authorize(subject *User, permission Permission, object *Object) (bool, error) {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+	role, found := svc.tuples[newTupleKey(subject, object)]
+	if !found {
+		return false, nil
+	}
+	return permitted(role, permission), nil
+}
+
+func (svc *AuthzService) ListAllObjects(subject *User, permission Permission, objectType ObjectType) ([]string, error) {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+	var out []string
+	for key, role := range svc.tuples {
+		if key.SubjectId != subject.Id || key.ObjectType != objectType {
+			continue
+		}
+		if permitted(role, permission) {
+			out = append(out, key.ObjectId)
+		}
+	}
+	return out, nil
+}
+
+func (svc *AuthzService) AssignRole(subject *User, object *Object, role Role) error {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+	svc.tuples[newTupleKey(subject, object)] = role
+	return nil
+}
+
+func (svc *AuthzService) UnassignRole(subject *User, object *Object) error {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+	delete(svc.tuples, newTupleKey(subject, object))
+	return nil
+}
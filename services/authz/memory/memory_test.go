@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"testing"
+
+	. "github.com/panyam/backbone/models"
+	. "github.com/panyam/relay/services/authz/core"
+)
+
+func TestAuthorizeDeniesByDefault(t *testing.T) {
+	svc := NewAuthzService()
+	subject := &User{Id: "u1"}
+	object := &Object{Type: ObjectTypeChannel, Id: "c1"}
+
+	ok, err := svc.Authorize(subject, PermissionView, object)
+	if err != nil {
+		t.Fatalf("Authorize returned error: %v", err)
+	}
+	if ok {
+		t.Fatalf("expected deny for a subject/object pair with no relation tuple")
+	}
+}
+
+func TestAuthorizeRoleHierarchy(t *testing.T) {
+	svc := NewAuthzService()
+	subject := &User{Id: "u1"}
+	object := &Object{Type: ObjectTypeChannel, Id: "c1"}
+
+	cases := []struct {
+		role       Role
+		permission Permission
+		want       bool
+	}{
+		{RoleViewer, PermissionView, true},
+		{RoleViewer, PermissionJoin, false},
+		{RoleMember, PermissionJoin, true},
+		{RoleMember, PermissionAdmin, false},
+		{RoleAdmin, PermissionAdmin, true},
+		{RoleOwner, PermissionAdmin, true},
+	}
+	for _, tc := range cases {
+		if err := svc.AssignRole(subject, object, tc.role); err != nil {
+			t.Fatalf("AssignRole(%v) returned error: %v", tc.role, err)
+		}
+		ok, err := svc.Authorize(subject, tc.permission, object)
+		if err != nil {
+			t.Fatalf("Authorize returned error: %v", err)
+		}
+		if ok != tc.want {
+			t.Fatalf("role %v, permission %v: got %v, want %v", tc.role, tc.permission, ok, tc.want)
+		}
+	}
+}
+
+func TestUnassignRoleRevokesAccess(t *testing.T) {
+	svc := NewAuthzService()
+	subject := &User{Id: "u1"}
+	object := &Object{Type: ObjectTypeChannel, Id: "c1"}
+
+	if err := svc.AssignRole(subject, object, RoleAdmin); err != nil {
+		t.Fatalf("AssignRole returned error: %v", err)
+	}
+	if err := svc.UnassignRole(subject, object); err != nil {
+		t.Fatalf("UnassignRole returned error: %v", err)
+	}
+	ok, err := svc.Authorize(subject, PermissionView, object)
+	if err != nil {
+		t.Fatalf("Authorize returned error: %v", err)
+	}
+	if ok {
+		t.Fatalf("expected deny after UnassignRole")
+	}
+}
+
+func TestListAllObjectsFiltersBySubjectAndType(t *testing.T) {
+	svc := NewAuthzService()
+	subject := &User{Id: "u1"}
+	other := &User{Id: "u2"}
+
+	svc.AssignRole(subject, &Object{Type: ObjectTypeChannel, Id: "c1"}, RoleMember)
+	svc.AssignRole(subject, &Object{Type: ObjectTypeChannel, Id: "c2"}, RoleViewer)
+	svc.AssignRole(subject, &Object{Type: ObjectTypeTeam, Id: "t1"}, RoleMember)
+	svc.AssignRole(other, &Object{Type: ObjectTypeChannel, Id: "c3"}, RoleMember)
+
+	ids, err := svc.ListAllObjects(subject, PermissionView, ObjectTypeChannel)
+	if err != nil {
+		t.Fatalf("ListAllObjects returned error: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("expected 2 channels visible to subject, got %d: %v", len(ids), ids)
+	}
+
+	ids, err = svc.ListAllObjects(subject, PermissionJoin, ObjectTypeChannel)
+	if err != nil {
+		t.Fatalf("ListAllObjects returned error: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "c1" {
+		t.Fatalf("expected only c1 (viewer role does not grant join), got %v", ids)
+	}
+}
@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	. "github.com/panyam/backbone/models"
+	authzcore "github.com/panyam/relay/services/authz/core"
+	. "github.com/panyam/relay/services/commands/core"
+)
+
+/**
+ * The built-in commands shipped out of the box, modeled on Mattermost's
+ * default command set.  RegisterBuiltins is the plug-in point: callers wire
+ * up their own ICommandService implementation and additional handlers
+ * (eg from a plugin) the same way, via RegisterCommand.
+ */
+type Registrar interface {
+	RegisterCommand(meta *CommandMetadata, handler CommandHandler) error
+}
+
+/**
+ * Registers the default command set against svc.  Application wiring (eg
+ * ServiceGroup construction) calls this once builtins and any plugins are
+ * both expected to be registered before the first CreateMessage call.
+ */
+func RegisterBuiltins(svc Registrar) error {
+	builtins := []struct {
+		meta    *CommandMetadata
+		handler CommandHandler
+	}{
+		{&CommandMetadata{Trigger: "/me", AutoCompleteHint: "[message]", Description: "Do an action", Scope: ChannelScope}, handleMe},
+		{&CommandMetadata{Trigger: "/msg", AutoCompleteHint: "[username] [message]", Description: "Send a direct message", Scope: TeamScope}, handleMsg},
+		{&CommandMetadata{Trigger: "/join", AutoCompleteHint: "[channel]", Description: "Join a channel", Scope: TeamScope}, handleJoin},
+		{&CommandMetadata{Trigger: "/leave", AutoCompleteHint: "", Description: "Leave the current channel", Scope: ChannelScope}, handleLeave},
+		{&CommandMetadata{Trigger: "/shrug", AutoCompleteHint: "[message]", Description: "Appends ¯\\_(ツ)_/¯ to your message", Scope: ChannelScope}, handleShrug},
+		{&CommandMetadata{Trigger: "/invite", AutoCompleteHint: "[username]", Description: "Invite a user to the current channel", Scope: ChannelScope, RequiredPermission: authzcore.PermissionInvite}, handleInvite},
+		{&CommandMetadata{Trigger: "/groupmsg", AutoCompleteHint: "[username...] [message]", Description: "Send a direct message to a group of users", Scope: TeamScope}, handleGroupMsg},
+	}
+	for _, b := range builtins {
+		if err := svc.RegisterCommand(b.meta, b.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func handleMe(ctx *CommandContext) (*CommandResponse, error) {
+	if ctx.Message == nil || len(ctx.Args) == 0 {
+		return nil, errors.New("/me requires a message")
+	}
+	ctx.Message.Body = "*" + strings.Join(ctx.Args, " ") + "*"
+	return &CommandResponse{Message: ctx.Message}, nil
+}
+
+func handleMsg(ctx *CommandContext) (*CommandResponse, error) {
+	if len(ctx.Args) < 2 {
+		return nil, errors.New("/msg requires a username and a message")
+	}
+	recipient, err := resolveUser(ctx, ctx.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	return &CommandResponse{Handled: true}, sendDirectMessage(ctx, []*User{recipient}, strings.Join(ctx.Args[1:], " "))
+}
+
+func handleJoin(ctx *CommandContext) (*CommandResponse, error) {
+	if len(ctx.Args) != 1 {
+		return nil, errors.New("/join requires exactly one channel name")
+	}
+	if ctx.Channels == nil {
+		return nil, errors.New("/join: no channel service configured")
+	}
+	channel, err := findChannelByName(ctx, ctx.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Channels.JoinChannel(ctx.User, channel, ctx.User); err != nil {
+		return nil, err
+	}
+	return &CommandResponse{Handled: true}, nil
+}
+
+func handleLeave(ctx *CommandContext) (*CommandResponse, error) {
+	if ctx.Channels == nil || ctx.Channel == nil {
+		return nil, errors.New("/leave: no channel to leave")
+	}
+	if err := ctx.Channels.LeaveChannel(ctx.User, ctx.Channel, ctx.User); err != nil {
+		return nil, err
+	}
+	return &CommandResponse{Handled: true}, nil
+}
+
+func handleShrug(ctx *CommandContext) (*CommandResponse, error) {
+	if ctx.Message == nil {
+		return nil, errors.New("/shrug requires a message")
+	}
+	msg := strings.TrimSpace(strings.Join(ctx.Args, " "))
+	if msg != "" {
+		msg += " "
+	}
+	ctx.Message.Body = msg + `¯\_(ツ)_/¯`
+	return &CommandResponse{Message: ctx.Message}, nil
+}
+
+func handleInvite(ctx *CommandContext) (*CommandResponse, error) {
+	if len(ctx.Args) != 1 {
+		return nil, errors.New("/invite requires exactly one username")
+	}
+	if ctx.Channels == nil || ctx.Channel == nil {
+		return nil, errors.New("/invite: no channel to invite into")
+	}
+	invitee, err := resolveUser(ctx, ctx.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Channels.JoinChannel(ctx.User, ctx.Channel, invitee); err != nil {
+		return nil, err
+	}
+	return &CommandResponse{Handled: true}, nil
+}
+
+func handleGroupMsg(ctx *CommandContext) (*CommandResponse, error) {
+	if len(ctx.Args) < 2 {
+		return nil, errors.New("/groupmsg requires at least one username and a message")
+	}
+	usernames, body := ctx.Args[:len(ctx.Args)-1], ctx.Args[len(ctx.Args)-1]
+	recipients := make([]*User, len(usernames))
+	for i, username := range usernames {
+		recipient, err := resolveUser(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		recipients[i] = recipient
+	}
+	return &CommandResponse{Handled: true}, sendDirectMessage(ctx, recipients, body)
+}
+
+// findChannelByName looks up one of ctx.Team's channels by Name, since
+// IChannelService has no lookup by name and /join only gives a handler the
+// channel's display name.
+func findChannelByName(ctx *CommandContext, name string) (*Channel, error) {
+	channels, err := ctx.Channels.ListChannels(ctx.User, ctx.User, ctx.Team)
+	if err != nil {
+		return nil, err
+	}
+	for _, channel := range channels {
+		if channel.Name == name {
+			return channel, nil
+		}
+	}
+	return nil, fmt.Errorf("commands: no channel named %s", name)
+}
+
+// resolveUser resolves a "[username]" command argument to a *User via
+// ctx.Users, erroring clearly if the handler wasn't given one.
+func resolveUser(ctx *CommandContext, username string) (*User, error) {
+	if ctx.Users == nil {
+		return nil, errors.New("commands: no user service configured")
+	}
+	return ctx.Users.GetUser(ctx.User, username)
+}
+
+// sendDirectMessage finds or creates the DM/group-DM channel for ctx.User
+// plus recipients and delivers body into it via ctx.Messages.CreateMessage.
+func sendDirectMessage(ctx *CommandContext, recipients []*User, body string) error {
+	if ctx.Channels == nil || ctx.Messages == nil {
+		return errors.New("commands: no channel/message service configured")
+	}
+	participants := append([]*User{ctx.User}, recipients...)
+	id := dmChannelId(participants)
+
+	channel, err := ctx.Channels.GetChannelById(ctx.User, id)
+	if err != nil {
+		channel = &Channel{Id: id, Team: ctx.Team}
+		if err := ctx.Channels.SaveChannel(ctx.User, channel, false); err != nil {
+			return err
+		}
+		for _, user := range participants {
+			if err := ctx.Channels.JoinChannel(ctx.User, channel, user); err != nil {
+				return err
+			}
+		}
+	}
+
+	return ctx.Messages.CreateMessage(ctx.User, &Message{Channel: channel, Sender: ctx.User, Body: body})
+}
+
+// dmChannelId derives a stable Channel.Id for the DM/group-DM between
+// participants, so repeated /msg or /groupmsg calls between the same users
+// land in the same channel instead of creating a new one every time.
+func dmChannelId(participants []*User) string {
+	ids := make([]string, len(participants))
+	for i, user := range participants {
+		ids[i] = idString(user.Id)
+	}
+	sort.Strings(ids)
+	return "dm:" + strings.Join(ids, ":")
+}
+
+// idString renders a backbone model id (typed interface{} since the backing
+// store may hand out string or numeric ids), the same way
+// services/presence/memory.stringifyId does for UserPresence.UserId.
+func idString(id interface{}) string {
+	switch v := id.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
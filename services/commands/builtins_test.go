@@ -0,0 +1,199 @@
+package commands
+
+import (
+	"testing"
+
+	. "github.com/panyam/backbone/models"
+	. "github.com/panyam/relay/services/commands/core"
+)
+
+type fakeChannels struct {
+	byId     map[string]*Channel
+	forTeam  []*Channel
+	joined   []string // "userId:channelId" pairs JoinChannel was called with
+	left     []string // "userId:channelId" pairs LeaveChannel was called with
+	saved    []*Channel
+	saveErr  error
+	joinErr  error
+	leaveErr error
+}
+
+func (f *fakeChannels) SaveChannel(caller *User, channel *Channel, override bool) error {
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	if f.byId == nil {
+		f.byId = map[string]*Channel{}
+	}
+	f.byId[channel.Id.(string)] = channel
+	f.saved = append(f.saved, channel)
+	return nil
+}
+
+func (f *fakeChannels) GetChannelById(caller *User, id string) (*Channel, error) {
+	if channel, ok := f.byId[id]; ok {
+		return channel, nil
+	}
+	return nil, errNotFound
+}
+
+func (f *fakeChannels) DeleteChannel(caller *User, channel *Channel) error { return nil }
+
+func (f *fakeChannels) ListChannels(caller *User, user *User, team *Team) ([]*Channel, error) {
+	return f.forTeam, nil
+}
+
+func (f *fakeChannels) JoinChannel(caller *User, channel *Channel, user *User) error {
+	if f.joinErr != nil {
+		return f.joinErr
+	}
+	f.joined = append(f.joined, idString(user.Id)+":"+idString(channel.Id))
+	return nil
+}
+
+func (f *fakeChannels) LeaveChannel(caller *User, channel *Channel, user *User) error {
+	if f.leaveErr != nil {
+		return f.leaveErr
+	}
+	f.left = append(f.left, idString(user.Id)+":"+idString(channel.Id))
+	return nil
+}
+
+type fakeUsers struct {
+	byUsername map[string]*User
+}
+
+func (f *fakeUsers) GetUser(caller *User, username string) (*User, error) {
+	if user, ok := f.byUsername[username]; ok {
+		return user, nil
+	}
+	return nil, errNotFound
+}
+
+type fakeMessages struct {
+	created []*Message
+}
+
+func (f *fakeMessages) CreateMessage(caller *User, message *Message) error {
+	f.created = append(f.created, message)
+	return nil
+}
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string { return "not found" }
+
+var errNotFound = notFoundError{}
+
+func TestHandleJoinJoinsChannelFoundByName(t *testing.T) {
+	user := &User{Id: "u1"}
+	team := &Team{Id: "t1"}
+	channel := &Channel{Id: "c1", Name: "general", Team: team}
+	channels := &fakeChannels{forTeam: []*Channel{channel}}
+	ctx := &CommandContext{Team: team, User: user, Args: []string{"general"}, Channels: channels}
+
+	resp, err := handleJoin(ctx)
+	if err != nil {
+		t.Fatalf("handleJoin returned error: %v", err)
+	}
+	if !resp.Handled {
+		t.Fatalf("expected Handled to be true")
+	}
+	if len(channels.joined) != 1 || channels.joined[0] != "u1:c1" {
+		t.Fatalf("expected JoinChannel to be called for u1/c1, got %v", channels.joined)
+	}
+}
+
+func TestHandleJoinErrorsForUnknownChannelName(t *testing.T) {
+	user := &User{Id: "u1"}
+	team := &Team{Id: "t1"}
+	channels := &fakeChannels{}
+	ctx := &CommandContext{Team: team, User: user, Args: []string{"nope"}, Channels: channels}
+
+	if _, err := handleJoin(ctx); err == nil {
+		t.Fatalf("expected an error for a channel name with no match")
+	}
+}
+
+func TestHandleLeaveLeavesCurrentChannel(t *testing.T) {
+	user := &User{Id: "u1"}
+	channel := &Channel{Id: "c1"}
+	channels := &fakeChannels{}
+	ctx := &CommandContext{Channel: channel, User: user, Channels: channels}
+
+	resp, err := handleLeave(ctx)
+	if err != nil {
+		t.Fatalf("handleLeave returned error: %v", err)
+	}
+	if !resp.Handled {
+		t.Fatalf("expected Handled to be true")
+	}
+	if len(channels.left) != 1 || channels.left[0] != "u1:c1" {
+		t.Fatalf("expected LeaveChannel to be called for u1/c1, got %v", channels.left)
+	}
+}
+
+func TestHandleInviteJoinsInviteeIntoCurrentChannel(t *testing.T) {
+	user := &User{Id: "u1"}
+	bob := &User{Id: "bob"}
+	channel := &Channel{Id: "c1"}
+	channels := &fakeChannels{}
+	users := &fakeUsers{byUsername: map[string]*User{"bob": bob}}
+	ctx := &CommandContext{Channel: channel, User: user, Args: []string{"bob"}, Channels: channels, Users: users}
+
+	resp, err := handleInvite(ctx)
+	if err != nil {
+		t.Fatalf("handleInvite returned error: %v", err)
+	}
+	if !resp.Handled {
+		t.Fatalf("expected Handled to be true")
+	}
+	if len(channels.joined) != 1 || channels.joined[0] != "bob:c1" {
+		t.Fatalf("expected JoinChannel to be called for bob/c1, got %v", channels.joined)
+	}
+}
+
+func TestHandleMsgCreatesDMChannelAndDeliversMessage(t *testing.T) {
+	alice := &User{Id: "alice"}
+	bob := &User{Id: "bob"}
+	team := &Team{Id: "t1"}
+	channels := &fakeChannels{}
+	users := &fakeUsers{byUsername: map[string]*User{"bob": bob}}
+	messages := &fakeMessages{}
+	ctx := &CommandContext{Team: team, User: alice, Args: []string{"bob", "hi", "there"}, Channels: channels, Users: users, Messages: messages}
+
+	resp, err := handleMsg(ctx)
+	if err != nil {
+		t.Fatalf("handleMsg returned error: %v", err)
+	}
+	if !resp.Handled {
+		t.Fatalf("expected Handled to be true")
+	}
+	if len(messages.created) != 1 || messages.created[0].Body != "hi there" {
+		t.Fatalf("expected a DM with body %q to be created, got %+v", "hi there", messages.created)
+	}
+	if len(channels.joined) != 2 {
+		t.Fatalf("expected both participants to be joined to the new DM channel, got %v", channels.joined)
+	}
+}
+
+func TestHandleMsgReusesExistingDMChannel(t *testing.T) {
+	alice := &User{Id: "alice"}
+	bob := &User{Id: "bob"}
+	team := &Team{Id: "t1"}
+	existing := &Channel{Id: "dm:alice:bob", Team: team}
+	channels := &fakeChannels{byId: map[string]*Channel{"dm:alice:bob": existing}}
+	users := &fakeUsers{byUsername: map[string]*User{"bob": bob}}
+	messages := &fakeMessages{}
+	ctx := &CommandContext{Team: team, User: alice, Args: []string{"bob", "hi"}, Channels: channels, Users: users, Messages: messages}
+
+	if _, err := handleMsg(ctx); err != nil {
+		t.Fatalf("handleMsg returned error: %v", err)
+	}
+	if len(channels.joined) != 0 {
+		t.Fatalf("expected no JoinChannel calls when the DM channel already exists, got %v", channels.joined)
+	}
+	if len(messages.created) != 1 || messages.created[0].Channel != existing {
+		t.Fatalf("expected the message to be delivered into the existing DM channel, got %+v", messages.created)
+	}
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	. "github.com/panyam/backbone/models"
+	authzcore "github.com/panyam/relay/services/authz/core"
+)
+
+/**
+ * The scope a command is registered against.  A TeamScope command is
+ * available in any channel belonging to a team (eg /join), while a
+ * ChannelScope command only makes sense within a single channel's context.
+ */
+type CommandScope int
+
+const (
+	ChannelScope CommandScope = iota
+	TeamScope
+)
+
+/**
+ * Metadata describing a registered slash command.  This is what drives
+ * auto-complete on the client as well as the permission check performed
+ * before a handler is invoked.
+ */
+type CommandMetadata struct {
+	// The trigger word including the leading slash, eg "/me".
+	Trigger string
+
+	// Short hint shown next to the trigger in auto-complete, eg "[message]".
+	AutoCompleteHint string
+
+	// Longer human readable description of what the command does.
+	Description string
+
+	// Whether this command is team wide or scoped to a single channel.
+	Scope CommandScope
+
+	// Permission the invoking user must hold (on Channel, or Team for a
+	// TeamScope command) for Execute to be attempted, checked via the same
+	// services/authz/core.Permission model IAuthzService.Authorize uses.
+	// Empty means no permission beyond being a participant is required.
+	RequiredPermission authzcore.Permission
+}
+
+/**
+ * The subset of services.IChannelService a command handler needs to perform
+ * a real /join, /leave or /invite side effect.  Declared locally (rather
+ * than importing the services package for IChannelService) since
+ * services/interface.go already imports this package for CommandContext --
+ * importing it back here would cycle, the same reason openapi.HttpBinding
+ * stays local instead of reusing rest.HttpBinding.
+ */
+type ChannelJoiner interface {
+	ListChannels(caller *User, user *User, team *Team) ([]*Channel, error)
+	JoinChannel(caller *User, channel *Channel, user *User) error
+	LeaveChannel(caller *User, channel *Channel, user *User) error
+	SaveChannel(caller *User, channel *Channel, override bool) error
+	GetChannelById(caller *User, id string) (*Channel, error)
+}
+
+// UserFinder is the subset of services.IUserService a command handler needs
+// to resolve a "[username]" argument to a *User, eg for /msg, /invite and
+// /groupmsg.
+type UserFinder interface {
+	GetUser(caller *User, username string) (*User, error)
+}
+
+// MessageSender is the subset of services.IMessageService a command handler
+// needs to deliver a message as a side effect, eg /msg and /groupmsg's DM.
+type MessageSender interface {
+	CreateMessage(caller *User, message *Message) error
+}
+
+/**
+ * Everything a command handler needs to do its work.  Handlers are free to
+ * mutate Message in place (eg /shrug appends "¯\_(ツ)_/¯") before it is
+ * persisted, or leave it nil to indicate no message should be persisted.
+ */
+type CommandContext struct {
+	Team    *Team
+	Channel *Channel
+	User    *User
+	Message *Message
+
+	// The raw command body split on whitespace, not including the trigger
+	// itself.  eg "/msg bob hello" -> ["bob", "hello"]
+	Args []string
+
+	// Services a handler needs to perform a real side effect (/join, /leave,
+	// /invite, /msg, /groupmsg). Left nil by callers that only exercise
+	// message-mutating commands like /me and /shrug.
+	Channels ChannelJoiner
+	Users    UserFinder
+	Messages MessageSender
+}
+
+/**
+ * The result of executing a command handler.
+ */
+type CommandResponse struct {
+	// If set, this is shown in place of the original message.  By default
+	// it is persisted and broadcast to all participants; see Ephemeral.
+	Message *Message
+
+	// If true, Message is rendered only to the invoking user and is never
+	// persisted or broadcast to other participants.
+	Ephemeral bool
+
+	// If true the handler fully handled the command as a side effect (eg
+	// /join, /leave) and no message should be created at all.
+	Handled bool
+}
+
+/**
+ * A CommandHandler implements the behaviour of a single slash command.
+ */
+type CommandHandler func(ctx *CommandContext) (*CommandResponse, error)
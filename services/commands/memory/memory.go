@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "github.com/panyam/backbone/models"
+	"github.com/panyam/relay/services"
+	authzcore "github.com/panyam/relay/services/authz/core"
+	. "github.com/panyam/relay/services/commands/core"
+)
+
+type registeredCommand struct {
+	meta    *CommandMetadata
+	handler CommandHandler
+}
+
+/**
+ * An in-memory ICommandService: a trigger -> handler registry plus the
+ * Execute dispatcher that parses a message body, enforces scope and
+ * RequiredPermission, and invokes the handler.  Authz is consulted only
+ * when a command declares a RequiredPermission; a nil Authz disables
+ * enforcement entirely, eg for tests that don't care about it.
+ */
+type CommandService struct {
+	mutex    sync.RWMutex
+	commands map[string]*registeredCommand
+	Authz    services.IAuthzService
+}
+
+func NewCommandService(authz services.IAuthzService) *CommandService {
+	return &CommandService{commands: make(map[string]*registeredCommand), Authz: authz}
+}
+
+func (svc *CommandService) RegisterCommand(meta *CommandMetadata, handler CommandHandler) error {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+	if _, exists := svc.commands[meta.Trigger]; exists {
+		return fmt.Errorf("commands: trigger %s already registered", meta.Trigger)
+	}
+	svc.commands[meta.Trigger] = &registeredCommand{meta: meta, handler: handler}
+	return nil
+}
+
+func (svc *CommandService) UnregisterCommand(trigger string) error {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+	delete(svc.commands, trigger)
+	return nil
+}
+
+func (svc *CommandService) GetCommand(trigger string) (*CommandMetadata, error) {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+	cmd, ok := svc.commands[trigger]
+	if !ok {
+		return nil, fmt.Errorf("commands: trigger %s not registered", trigger)
+	}
+	return cmd.meta, nil
+}
+
+func (svc *CommandService) ListCommands(scope CommandScope) ([]*CommandMetadata, error) {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+	var out []*CommandMetadata
+	for _, cmd := range svc.commands {
+		if cmd.meta.Scope == scope {
+			out = append(out, cmd.meta)
+		}
+	}
+	return out, nil
+}
+
+func (svc *CommandService) Execute(ctx *CommandContext) (*CommandResponse, error) {
+	if ctx.Message == nil || !strings.HasPrefix(ctx.Message.Body, "/") {
+		return nil, fmt.Errorf("commands: message body does not start with a trigger")
+	}
+	fields := strings.Fields(ctx.Message.Body)
+	trigger := fields[0]
+
+	svc.mutex.RLock()
+	cmd, ok := svc.commands[trigger]
+	svc.mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("commands: trigger %s not registered", trigger)
+	}
+
+	if cmd.meta.Scope == TeamScope && ctx.Team == nil {
+		return nil, fmt.Errorf("commands: %s requires a team", trigger)
+	}
+	if cmd.meta.Scope == ChannelScope && ctx.Channel == nil {
+		return nil, fmt.Errorf("commands: %s requires a channel", trigger)
+	}
+
+	if cmd.meta.RequiredPermission != "" && svc.Authz != nil {
+		object, err := commandObject(cmd.meta.Scope, ctx)
+		if err != nil {
+			return nil, err
+		}
+		allowed, err := svc.Authz.Authorize(ctx.User, cmd.meta.RequiredPermission, object)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, fmt.Errorf("commands: %s requires %s permission", trigger, cmd.meta.RequiredPermission)
+		}
+	}
+
+	ctx.Args = fields[1:]
+	return cmd.handler(ctx)
+}
+
+// commandObject builds the authz.Object a command's RequiredPermission is
+// checked against: the team for a TeamScope command, the channel otherwise.
+func commandObject(scope CommandScope, ctx *CommandContext) (*authzcore.Object, error) {
+	if scope == TeamScope {
+		id, err := objectId(ctx.Team.Id)
+		return &authzcore.Object{Type: authzcore.ObjectTypeTeam, Id: id}, err
+	}
+	id, err := objectId(ctx.Channel.Id)
+	return &authzcore.Object{Type: authzcore.ObjectTypeChannel, Id: id}, err
+}
+
+// objectId stringifies a backbone model id (Team.Id/Channel.Id, typed
+// interface{} since the backing store may hand out string or numeric ids)
+// for the authz/core.Object.Id field, the same way authz.userId does for
+// subjects.
+func objectId(id interface{}) (string, error) {
+	switch v := id.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case int:
+		return strconv.Itoa(v), nil
+	default:
+		return "", fmt.Errorf("commands: unsupported object id type %T", id)
+	}
+}
+
+var _ services.ICommandService = (*CommandService)(nil)
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"testing"
+
+	. "github.com/panyam/backbone/models"
+	authzcore "github.com/panyam/relay/services/authz/core"
+	. "github.com/panyam/relay/services/commands/core"
+)
+
+type fakeAuthz struct {
+	allow bool
+}
+
+func (f *fakeAuthz) Authorize(subject *User, permission authzcore.Permission, object *authzcore.Object) (bool, error) {
+	return f.allow, nil
+}
+func (f *fakeAuthz) ListAllObjects(subject *User, permission authzcore.Permission, objectType authzcore.ObjectType) ([]string, error) {
+	return nil, nil
+}
+func (f *fakeAuthz) AssignRole(subject *User, object *authzcore.Object, role authzcore.Role) error {
+	return nil
+}
+func (f *fakeAuthz) UnassignRole(subject *User, object *authzcore.Object) error { return nil }
+
+func shrugMeta() *CommandMetadata {
+	return &CommandMetadata{Trigger: "/shrug", Scope: ChannelScope}
+}
+
+func shrugHandler(ctx *CommandContext) (*CommandResponse, error) {
+	ctx.Message.Body = "shrugged"
+	return &CommandResponse{Message: ctx.Message}, nil
+}
+
+func TestRegisterCommandRejectsDuplicateTrigger(t *testing.T) {
+	svc := NewCommandService(nil)
+	if err := svc.RegisterCommand(shrugMeta(), shrugHandler); err != nil {
+		t.Fatalf("RegisterCommand returned error: %v", err)
+	}
+	if err := svc.RegisterCommand(shrugMeta(), shrugHandler); err == nil {
+		t.Fatalf("expected an error re-registering /shrug")
+	}
+}
+
+func TestExecuteDispatchesToRegisteredHandler(t *testing.T) {
+	svc := NewCommandService(nil)
+	svc.RegisterCommand(&CommandMetadata{Trigger: "/me", Scope: ChannelScope}, func(ctx *CommandContext) (*CommandResponse, error) {
+		ctx.Message.Body = "*" + ctx.Args[0] + "*"
+		return &CommandResponse{Message: ctx.Message}, nil
+	})
+
+	channel := &Channel{Id: "c1"}
+	ctx := &CommandContext{Channel: channel, User: &User{Id: "u1"}, Message: &Message{Body: "/me waves"}}
+	resp, err := svc.Execute(ctx)
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if resp.Message.Body != "*waves*" {
+		t.Fatalf("got body %q, want *waves*", resp.Message.Body)
+	}
+}
+
+func TestExecuteUnregisteredTriggerErrors(t *testing.T) {
+	svc := NewCommandService(nil)
+	_, err := svc.Execute(&CommandContext{Message: &Message{Body: "/nope"}})
+	if err == nil {
+		t.Fatalf("expected an error for an unregistered trigger")
+	}
+}
+
+func TestExecuteRequiresScopeTarget(t *testing.T) {
+	svc := NewCommandService(nil)
+	svc.RegisterCommand(&CommandMetadata{Trigger: "/join", Scope: TeamScope}, func(ctx *CommandContext) (*CommandResponse, error) {
+		return &CommandResponse{Handled: true}, nil
+	})
+	_, err := svc.Execute(&CommandContext{Message: &Message{Body: "/join general"}})
+	if err == nil {
+		t.Fatalf("expected an error executing a TeamScope command with no Team set")
+	}
+}
+
+func TestExecuteEnforcesRequiredPermission(t *testing.T) {
+	svc := NewCommandService(&fakeAuthz{allow: false})
+	svc.RegisterCommand(&CommandMetadata{Trigger: "/invite", Scope: ChannelScope, RequiredPermission: authzcore.PermissionInvite}, func(ctx *CommandContext) (*CommandResponse, error) {
+		return &CommandResponse{Handled: true}, nil
+	})
+	ctx := &CommandContext{Channel: &Channel{Id: "c1"}, User: &User{Id: "u1"}, Message: &Message{Body: "/invite bob"}}
+	_, err := svc.Execute(ctx)
+	if err == nil {
+		t.Fatalf("expected Execute to deny /invite when Authz disallows PermissionInvite")
+	}
+
+	svc.Authz = &fakeAuthz{allow: true}
+	resp, err := svc.Execute(ctx)
+	if err != nil {
+		t.Fatalf("Execute returned error once Authz allows it: %v", err)
+	}
+	if !resp.Handled {
+		t.Fatalf("expected the handler's response to be returned")
+	}
+}
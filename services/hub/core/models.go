@@ -0,0 +1,46 @@
+package core
+
+import (
+	. "github.com/panyam/backbone/models"
+)
+
+/**
+ * The kind of real-time event being fanned out by the hub.
+ */
+type EventType string
+
+const (
+	MessageCreated        EventType = "message_created"
+	MessageDeleted        EventType = "message_deleted"
+	UserJoinedChannel     EventType = "user_joined_channel"
+	UserLeftChannel       EventType = "user_left_channel"
+	RegistrationConfirmed EventType = "registration_confirmed"
+	Typing                EventType = "typing"
+)
+
+/**
+ * A single fan-out event.  Only the fields relevant to Type are expected to
+ * be populated, eg a Typing event has no Message.
+ */
+type Event struct {
+	Type    EventType
+	Team    *Team
+	Channel *Channel
+	User    *User
+	Message *Message
+}
+
+/**
+ * Abstraction over a single client's live connection so the hub does not
+ * need to know about net/http or the websocket wire format directly.  The
+ * REST-facing /ws handler is expected to wrap a websocket connection in an
+ * implementation of this interface before registering it with the hub.
+ */
+type Connection interface {
+	// Pushes a single event down to the client.  Implementations are
+	// expected to serialize Event as JSON.
+	Send(event *Event) error
+
+	// Closes the underlying transport.
+	Close() error
+}
@@ -0,0 +1,199 @@
+package memory
+
+import (
+	"sync"
+
+	. "github.com/panyam/backbone/models"
+	. "github.com/panyam/relay/services/hub/core"
+
+	"github.com/panyam/relay/services"
+)
+
+type subscriber struct {
+	conn Connection
+	user *User
+}
+
+/**
+ * An in-memory IHubService: per-team and per-channel connection registries
+ * plus a (user, channel) -> visible access cache so Publish never has to
+ * call back into Channels on the hot path.  Subscribe populates the cache
+ * from ListChannels; Publish itself keeps it current for
+ * UserJoinedChannel/UserLeftChannel events so a later Publish for that
+ * channel reflects the membership change without a storage round trip.
+ *
+ * Ids (User.Id, Team.Id, Channel.Id) are kept as the interface{} the
+ * backbone models hand out rather than assumed to be strings, the same way
+ * services/authz/memory's tupleKey keys on SubjectId interface{}.
+ */
+type HubService struct {
+	mutex sync.RWMutex
+
+	teamConns    map[interface{}]map[Connection]*subscriber
+	channelConns map[interface{}]map[Connection]*subscriber
+	connTeam     map[Connection]interface{}
+	connChannels map[Connection][]interface{}
+
+	// userId -> that user's live connections, across however many teams
+	// they're subscribed from. UserJoinedChannel uses this to register an
+	// already-connected user into channelConns for a channel they joined
+	// after Subscribe ran, so Publish can find them without a resubscribe.
+	userConns map[interface{}]map[Connection]*subscriber
+
+	// userId -> channelId -> whether user may see that channel's events.
+	access map[interface{}]map[interface{}]bool
+
+	Channels services.IChannelService
+}
+
+func NewHubService(channels services.IChannelService) *HubService {
+	return &HubService{
+		teamConns:    map[interface{}]map[Connection]*subscriber{},
+		channelConns: map[interface{}]map[Connection]*subscriber{},
+		connTeam:     map[Connection]interface{}{},
+		connChannels: map[Connection][]interface{}{},
+		userConns:    map[interface{}]map[Connection]*subscriber{},
+		access:       map[interface{}]map[interface{}]bool{},
+		Channels:     channels,
+	}
+}
+
+func (svc *HubService) Subscribe(user *User, team *Team, conn Connection) error {
+	channels, err := svc.Channels.ListChannels(user, user, team)
+	if err != nil {
+		return err
+	}
+
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+
+	if svc.teamConns[team.Id] == nil {
+		svc.teamConns[team.Id] = map[Connection]*subscriber{}
+	}
+	svc.teamConns[team.Id][conn] = &subscriber{conn: conn, user: user}
+	svc.connTeam[conn] = team.Id
+
+	sub := &subscriber{conn: conn, user: user}
+	if svc.userConns[user.Id] == nil {
+		svc.userConns[user.Id] = map[Connection]*subscriber{}
+	}
+	svc.userConns[user.Id][conn] = sub
+
+	channelIds := make([]interface{}, 0, len(channels))
+	for _, channel := range channels {
+		if svc.channelConns[channel.Id] == nil {
+			svc.channelConns[channel.Id] = map[Connection]*subscriber{}
+		}
+		svc.channelConns[channel.Id][conn] = sub
+		channelIds = append(channelIds, channel.Id)
+		svc.grantAccessLocked(user.Id, channel.Id)
+	}
+	svc.connChannels[conn] = channelIds
+	return nil
+}
+
+func (svc *HubService) Unsubscribe(user *User, conn Connection) error {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+
+	if teamId, ok := svc.connTeam[conn]; ok {
+		delete(svc.teamConns[teamId], conn)
+		delete(svc.connTeam, conn)
+	}
+	for _, channelId := range svc.connChannels[conn] {
+		delete(svc.channelConns[channelId], conn)
+	}
+	delete(svc.connChannels, conn)
+	delete(svc.userConns[user.Id], conn)
+	return nil
+}
+
+func (svc *HubService) Publish(event *Event) error {
+	svc.mutex.Lock()
+	switch event.Type {
+	case UserJoinedChannel:
+		if event.User != nil && event.Channel != nil {
+			svc.grantAccessLocked(event.User.Id, event.Channel.Id)
+			svc.addUserToChannelLocked(event.User.Id, event.Channel.Id)
+		}
+	case UserLeftChannel:
+		if event.User != nil && event.Channel != nil {
+			svc.revokeAccessLocked(event.User.Id, event.Channel.Id)
+			svc.removeUserFromChannelLocked(event.User.Id, event.Channel.Id)
+		}
+	}
+
+	var recipients []*subscriber
+	if event.Channel != nil {
+		for _, sub := range svc.channelConns[event.Channel.Id] {
+			if svc.canSeeLocked(sub.user.Id, event.Channel.Id) {
+				recipients = append(recipients, sub)
+			}
+		}
+	} else if event.Team != nil {
+		for _, sub := range svc.teamConns[event.Team.Id] {
+			recipients = append(recipients, sub)
+		}
+	}
+	svc.mutex.Unlock()
+
+	var firstErr error
+	for _, sub := range recipients {
+		if err := sub.conn.Send(event); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (svc *HubService) CanSee(user *User, channel *Channel) bool {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+	return svc.canSeeLocked(user.Id, channel.Id)
+}
+
+func (svc *HubService) canSeeLocked(userId interface{}, channelId interface{}) bool {
+	return svc.access[userId][channelId]
+}
+
+// addUserToChannelLocked registers every live connection userId already has
+// (from Subscribe, possibly to more than one team) into channelConns for
+// channelId, so a user who joins a channel mid-session starts receiving its
+// events immediately instead of only after their next Subscribe.
+func (svc *HubService) addUserToChannelLocked(userId interface{}, channelId interface{}) {
+	for conn, sub := range svc.userConns[userId] {
+		if svc.channelConns[channelId] == nil {
+			svc.channelConns[channelId] = map[Connection]*subscriber{}
+		}
+		svc.channelConns[channelId][conn] = sub
+		svc.connChannels[conn] = append(svc.connChannels[conn], channelId)
+	}
+}
+
+// removeUserFromChannelLocked undoes addUserToChannelLocked/Subscribe's
+// registration of userId's connections into channelConns for channelId.
+func (svc *HubService) removeUserFromChannelLocked(userId interface{}, channelId interface{}) {
+	for conn := range svc.userConns[userId] {
+		delete(svc.channelConns[channelId], conn)
+		channelIds := svc.connChannels[conn]
+		for i, id := range channelIds {
+			if id == channelId {
+				svc.connChannels[conn] = append(channelIds[:i], channelIds[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (svc *HubService) grantAccessLocked(userId interface{}, channelId interface{}) {
+	if svc.access[userId] == nil {
+		svc.access[userId] = map[interface{}]bool{}
+	}
+	svc.access[userId][channelId] = true
+}
+
+func (svc *HubService) revokeAccessLocked(userId interface{}, channelId interface{}) {
+	delete(svc.access[userId], channelId)
+}
+
+var _ services.IHubService = (*HubService)(nil)
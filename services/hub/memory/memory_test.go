@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"testing"
+
+	. "github.com/panyam/backbone/models"
+	. "github.com/panyam/relay/services/hub/core"
+)
+
+type fakeChannels struct {
+	forUser map[string][]*Channel
+}
+
+func (f *fakeChannels) SaveChannel(caller *User, channel *Channel, override bool) error { return nil }
+func (f *fakeChannels) GetChannelById(caller *User, id string) (*Channel, error)        { return nil, nil }
+func (f *fakeChannels) DeleteChannel(caller *User, channel *Channel) error              { return nil }
+func (f *fakeChannels) ListChannels(caller *User, user *User, team *Team) ([]*Channel, error) {
+	return f.forUser[user.Id.(string)], nil
+}
+func (f *fakeChannels) JoinChannel(caller *User, channel *Channel, user *User) error  { return nil }
+func (f *fakeChannels) LeaveChannel(caller *User, channel *Channel, user *User) error { return nil }
+
+type fakeConn struct {
+	received []*Event
+	closed   bool
+}
+
+func (c *fakeConn) Send(event *Event) error { c.received = append(c.received, event); return nil }
+func (c *fakeConn) Close() error            { c.closed = true; return nil }
+
+func TestSubscribeBootstrapsAccessFromListChannels(t *testing.T) {
+	user := &User{Id: "u1"}
+	team := &Team{Id: "t1"}
+	channel := &Channel{Id: "c1"}
+	channels := &fakeChannels{forUser: map[string][]*Channel{"u1": {channel}}}
+	hub := NewHubService(channels)
+	conn := &fakeConn{}
+
+	if err := hub.Subscribe(user, team, conn); err != nil {
+		t.Fatalf("Subscribe returned error: %v", err)
+	}
+	if !hub.CanSee(user, channel) {
+		t.Fatalf("expected CanSee to be true for a channel ListChannels returned")
+	}
+	if hub.CanSee(user, &Channel{Id: "other"}) {
+		t.Fatalf("expected CanSee to be false for a channel the user was never subscribed to")
+	}
+}
+
+func TestPublishDeliversOnlyToVisibleSubscribers(t *testing.T) {
+	alice := &User{Id: "alice"}
+	bob := &User{Id: "bob"}
+	team := &Team{Id: "t1"}
+	channel := &Channel{Id: "c1"}
+	channels := &fakeChannels{forUser: map[string][]*Channel{"alice": {channel}}}
+	hub := NewHubService(channels)
+
+	aliceConn := &fakeConn{}
+	bobConn := &fakeConn{}
+	hub.Subscribe(alice, team, aliceConn)
+	hub.Subscribe(bob, team, bobConn) // bob has no channels visible
+
+	event := &Event{Type: MessageCreated, Channel: channel}
+	if err := hub.Publish(event); err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	if len(aliceConn.received) != 1 {
+		t.Fatalf("expected alice's connection to receive the event, got %d", len(aliceConn.received))
+	}
+	if len(bobConn.received) != 0 {
+		t.Fatalf("expected bob's connection to be filtered out, got %d", len(bobConn.received))
+	}
+}
+
+func TestUnsubscribeStopsFurtherDelivery(t *testing.T) {
+	user := &User{Id: "u1"}
+	team := &Team{Id: "t1"}
+	channel := &Channel{Id: "c1"}
+	channels := &fakeChannels{forUser: map[string][]*Channel{"u1": {channel}}}
+	hub := NewHubService(channels)
+	conn := &fakeConn{}
+	hub.Subscribe(user, team, conn)
+
+	if err := hub.Unsubscribe(user, conn); err != nil {
+		t.Fatalf("Unsubscribe returned error: %v", err)
+	}
+	hub.Publish(&Event{Type: MessageCreated, Channel: channel})
+	if len(conn.received) != 0 {
+		t.Fatalf("expected no events after Unsubscribe, got %d", len(conn.received))
+	}
+}
+
+func TestUserJoinedChannelDeliversFutureEventsWithoutResubscribe(t *testing.T) {
+	user := &User{Id: "u1"}
+	team := &Team{Id: "t1"}
+	channel := &Channel{Id: "c1"}
+	channels := &fakeChannels{forUser: map[string][]*Channel{"u1": {}}}
+	hub := NewHubService(channels)
+	conn := &fakeConn{}
+	hub.Subscribe(user, team, conn)
+
+	if hub.CanSee(user, channel) {
+		t.Fatalf("expected CanSee to be false before UserJoinedChannel")
+	}
+
+	hub.Publish(&Event{Type: UserJoinedChannel, User: user, Channel: channel})
+	if !hub.CanSee(user, channel) {
+		t.Fatalf("expected CanSee to be true after a UserJoinedChannel event")
+	}
+
+	hub.Publish(&Event{Type: MessageCreated, Channel: channel})
+	if len(conn.received) != 1 {
+		t.Fatalf("expected the already-subscribed connection to receive events for a channel joined mid-session, got %d", len(conn.received))
+	}
+}
+
+func TestUserLeftChannelRevokesAccess(t *testing.T) {
+	user := &User{Id: "u1"}
+	team := &Team{Id: "t1"}
+	channel := &Channel{Id: "c1"}
+	channels := &fakeChannels{forUser: map[string][]*Channel{"u1": {channel}}}
+	hub := NewHubService(channels)
+	conn := &fakeConn{}
+	hub.Subscribe(user, team, conn)
+
+	hub.Publish(&Event{Type: UserLeftChannel, User: user, Channel: channel})
+	if hub.CanSee(user, channel) {
+		t.Fatalf("expected CanSee to be false after a UserLeftChannel event")
+	}
+
+	hub.Publish(&Event{Type: MessageCreated, Channel: channel})
+	if len(conn.received) != 0 {
+		t.Fatalf("expected no further events once access was revoked, got %d", len(conn.received))
+	}
+}
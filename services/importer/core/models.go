@@ -0,0 +1,36 @@
+package core
+
+/**
+ * Where a bulk import job is in its lifecycle.
+ */
+type ImportStatus string
+
+const (
+	ImportPending   ImportStatus = "pending"
+	ImportRunning   ImportStatus = "running"
+	ImportCompleted ImportStatus = "completed"
+	ImportFailed    ImportStatus = "failed"
+)
+
+/**
+ * A single line of what an import did or, in dry-run mode, would have done
+ * -- eg "create user bob" or "skip channel general: already exists".
+ */
+type ImportDiff struct {
+	Kind    string // "user", "team", "channel", "message"
+	Id      string
+	Action  string // "create", "update", "skip"
+	Message string
+}
+
+/**
+ * Tracks the progress of one POST /admin/import call so GET
+ * /admin/import/{jobId} has something to poll.
+ */
+type ImportJob struct {
+	Id     string
+	Status ImportStatus
+	DryRun bool
+	Diffs  []*ImportDiff
+	Error  string
+}
@@ -0,0 +1,185 @@
+package importer
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	. "github.com/panyam/backbone/models"
+	. "github.com/panyam/relay/services"
+	. "github.com/panyam/relay/services/authz/core"
+)
+
+// objectId stringifies a backbone model id (typed interface{} since the
+// backing store may hand out string or numeric ids), the same way
+// services/authz.userId and services/commands/memory.objectId do.
+func objectId(id interface{}) (string, error) {
+	switch v := id.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case int:
+		return strconv.Itoa(v), nil
+	default:
+		return "", fmt.Errorf("importer: unsupported id type %T", id)
+	}
+}
+
+/**
+ * Streams a users.json / teams.json / channels.json / messages/<channel>.jsonl
+ * archive out for a given org, the mirror image of Importer.  Only channels
+ * Authz reports caller may view are included, so a caller exporting an org
+ * they are not an admin of still gets a usable (if partial) archive rather
+ * than an error.
+ */
+type Exporter struct {
+	Users    IUserService
+	Teams    ITeamService
+	Channels IChannelService
+	Messages IMessageService
+	Authz    IAuthzService
+}
+
+func NewExporter(users IUserService, teams ITeamService, channels IChannelService, messages IMessageService, authz IAuthzService) *Exporter {
+	return &Exporter{Users: users, Teams: teams, Channels: channels, Messages: messages, Authz: authz}
+}
+
+/**
+ * Writes the archive for org to out.
+ */
+func (exp *Exporter) Export(caller *User, org string, out io.Writer) error {
+	zw := zip.NewWriter(out)
+	defer zw.Close()
+
+	teams, err := exp.Teams.GetTeamsInOrg(caller, org, 0, 1<<30)
+	if err != nil {
+		return fmt.Errorf("listing teams for %s: %w", org, err)
+	}
+	if err := writeJSONEntry(zw, "teams.json", teams); err != nil {
+		return err
+	}
+
+	var visibleChannels []*Channel
+	for _, team := range teams {
+		channels, err := exp.Channels.ListChannels(caller, caller, team)
+		if err != nil {
+			return fmt.Errorf("listing channels for team %s: %w", team.Name, err)
+		}
+		for _, channel := range channels {
+			channelId, err := objectId(channel.Id)
+			if err != nil {
+				return fmt.Errorf("channel %v: %w", channel.Id, err)
+			}
+			visible, err := exp.Authz.Authorize(caller, PermissionView, &Object{Type: ObjectTypeChannel, Id: channelId})
+			if err != nil {
+				return fmt.Errorf("checking view access to channel %s: %w", channelId, err)
+			}
+			if !visible {
+				continue
+			}
+			visibleChannels = append(visibleChannels, channel)
+		}
+	}
+	if err := writeJSONEntry(zw, "channels.json", visibleChannels); err != nil {
+		return err
+	}
+
+	// users.json must carry every user a message's Sender refers to, not
+	// just the caller -- Importer.importMessagesFile hard-fails if a
+	// message's sender isn't present there. A first pass over each channel's
+	// messages collects the senders so users.json can be written before the
+	// messages themselves are streamed to the zip.
+	users := map[interface{}]*User{caller.Id: caller}
+	for _, channel := range visibleChannels {
+		if err := exp.collectSenders(caller, channel, users); err != nil {
+			return err
+		}
+	}
+	userList := make([]*User, 0, len(users))
+	for _, u := range users {
+		userList = append(userList, u)
+	}
+	if err := writeJSONEntry(zw, "users.json", userList); err != nil {
+		return err
+	}
+
+	for _, channel := range visibleChannels {
+		if err := exp.exportMessages(zw, caller, channel); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// collectSenders pages through channel's messages, recording each distinct
+// Sender into users so it can be folded into users.json.
+func (exp *Exporter) collectSenders(caller *User, channel *Channel, users map[interface{}]*User) error {
+	const pageSize = 100
+	for offset := 0; ; offset += pageSize {
+		messages, err := exp.Messages.GetMessages(caller, channel, caller, offset, pageSize)
+		if err != nil {
+			return fmt.Errorf("listing messages for channel %v: %w", channel.Id, err)
+		}
+		if len(messages) == 0 {
+			break
+		}
+		for _, message := range messages {
+			if message.Sender != nil {
+				users[message.Sender.Id] = message.Sender
+			}
+		}
+		if len(messages) < pageSize {
+			break
+		}
+	}
+	return nil
+}
+
+func (exp *Exporter) exportMessages(zw *zip.Writer, caller *User, channel *Channel) error {
+	channelId, err := objectId(channel.Id)
+	if err != nil {
+		return fmt.Errorf("channel %v: %w", channel.Id, err)
+	}
+	w, err := zw.Create("messages/" + channelId + ".jsonl")
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+
+	const pageSize = 100
+	for offset := 0; ; offset += pageSize {
+		messages, err := exp.Messages.GetMessages(caller, channel, caller, offset, pageSize)
+		if err != nil {
+			return fmt.Errorf("listing messages for channel %s: %w", channelId, err)
+		}
+		if len(messages) == 0 {
+			break
+		}
+		for _, message := range messages {
+			line, err := json.Marshal(message)
+			if err != nil {
+				return err
+			}
+			if _, err := bw.Write(append(line, '\n')); err != nil {
+				return err
+			}
+		}
+		if len(messages) < pageSize {
+			break
+		}
+	}
+	return bw.Flush()
+}
+
+func writeJSONEntry(zw *zip.Writer, name string, data interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,106 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	. "github.com/panyam/backbone/models"
+	. "github.com/panyam/relay/services/authz/core"
+)
+
+type fakeAuthz struct{}
+
+func (f *fakeAuthz) Authorize(subject *User, permission Permission, object *Object) (bool, error) {
+	return true, nil
+}
+func (f *fakeAuthz) ListAllObjects(subject *User, permission Permission, objectType ObjectType) ([]string, error) {
+	return nil, nil
+}
+func (f *fakeAuthz) AssignRole(subject *User, object *Object, role Role) error { return nil }
+func (f *fakeAuthz) UnassignRole(subject *User, object *Object) error          { return nil }
+
+func readZipEntry(t *testing.T, zr *zip.Reader, name string, v interface{}) {
+	t.Helper()
+	f, err := zr.Open(name)
+	if err != nil {
+		t.Fatalf("opening %s: %v", name, err)
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(v); err != nil {
+		t.Fatalf("decoding %s: %v", name, err)
+	}
+}
+
+func TestExportIncludesEveryMessageSenderInUsersJson(t *testing.T) {
+	caller := &User{Id: "admin", Username: "admin"}
+	alice := &User{Id: "u1", Username: "alice"}
+	bob := &User{Id: "u2", Username: "bob"}
+	team := &Team{Id: "t1", Name: "Acme"}
+	channel := &Channel{Id: "c1", Team: team, Name: "general"}
+
+	teams := &fakeTeams{byId: map[string]*Team{"t1": team}}
+	channels := &fakeChannels{byId: map[string]*Channel{"c1": channel}}
+	messages := &fakeMessages{
+		created: []*Message{
+			{Id: "m1", Channel: channel, Sender: alice, Body: "hello"},
+			{Id: "m2", Channel: channel, Sender: bob, Body: "hi back"},
+		},
+	}
+	exp := NewExporter(newFakeUsers(), fakeTeamsLister{teams, []*Team{team}}, channelsLister{channels, []*Channel{channel}}, messagesLister{messages}, &fakeAuthz{})
+
+	out := &bytes.Buffer{}
+	if err := exp.Export(caller, "acme", out); err != nil {
+		t.Fatalf("Export returned error: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("reopening archive: %v", err)
+	}
+
+	var users []*User
+	readZipEntry(t, zr, "users.json", &users)
+	byId := map[string]bool{}
+	for _, u := range users {
+		byId[u.Id.(string)] = true
+	}
+	if !byId["admin"] || !byId["u1"] || !byId["u2"] {
+		t.Fatalf("expected users.json to include the caller and every message sender, got %+v", users)
+	}
+}
+
+// fakeTeamsLister/channelsLister/messagesLister let the export tests return
+// canned listing results without changing the importer-side fakes, which
+// intentionally return nil from their own listing methods (they're unused by
+// Importer).
+
+type fakeTeamsLister struct {
+	*fakeTeams
+	teams []*Team
+}
+
+func (f fakeTeamsLister) GetTeamsInOrg(caller *User, org string, offset int, count int) ([]*Team, error) {
+	return f.teams, nil
+}
+
+type channelsLister struct {
+	*fakeChannels
+	channels []*Channel
+}
+
+func (f channelsLister) ListChannels(caller *User, user *User, team *Team) ([]*Channel, error) {
+	return f.channels, nil
+}
+
+type messagesLister struct {
+	*fakeMessages
+}
+
+func (f messagesLister) GetMessages(caller *User, channel *Channel, user *User, offset int, count int) ([]*Message, error) {
+	if offset > 0 {
+		return nil, nil
+	}
+	return f.created, nil
+}
@@ -0,0 +1,266 @@
+package importer
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	. "github.com/panyam/backbone/models"
+	. "github.com/panyam/relay/services"
+	. "github.com/panyam/relay/services/importer/core"
+)
+
+// Archive entry names, matching the Slack/Mattermost migration layout.
+const (
+	UsersFile    = "users.json"
+	TeamsFile    = "teams.json"
+	ChannelsFile = "channels.json"
+	MessagesDir  = "messages/"
+)
+
+type userRecord struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type teamRecord struct {
+	Id   string `json:"id"`
+	Org  string `json:"org"`
+	Name string `json:"name"`
+}
+
+type channelRecord struct {
+	Id     string `json:"id"`
+	TeamId string `json:"team_id"`
+	Name   string `json:"name"`
+}
+
+type messageRecord struct {
+	Id        string `json:"id"`
+	ChannelId string `json:"channel_id"`
+	SenderId  string `json:"sender_id"`
+	Body      string `json:"body"`
+}
+
+/**
+ * Drives ITeamService/IChannelService/IUserService/IMessageService from a
+ * zipped archive in the Slack/Mattermost migration layout
+ * (users.json, teams.json, channels.json, messages/<channel>.jsonl), in
+ * dependency order (users, then teams, then channels, then messages).  A
+ * failure partway through rolls back the teams, channels and messages this
+ * Import call itself created, best effort, before returning the error.
+ * Users it created are not rolled back and stay in place.  In dry-run mode nothing is
+ * written in the first place; ImportJob.Diffs instead reports what each
+ * entity would have resulted in.
+ */
+type Importer struct {
+	Users    IUserService
+	Teams    ITeamService
+	Channels IChannelService
+	Messages IMessageService
+}
+
+func NewImporter(users IUserService, teams ITeamService, channels IChannelService, messages IMessageService) *Importer {
+	return &Importer{Users: users, Teams: teams, Channels: channels, Messages: messages}
+}
+
+// Tracks everything created so far during one Import call, so a failure
+// partway through knows what to undo.
+type importState struct {
+	usersById    map[string]*User
+	teamsById    map[string]*Team
+	channelsById map[string]*Channel
+	messages     []*Message
+}
+
+/**
+ * Imports archive (a zip.Reader over the uploaded multipart file) as caller.
+ * If dryRun is true, no service methods that mutate state are called --
+ * ImportJob.Diffs instead records what each entity would have resulted in.
+ */
+func (imp *Importer) Import(caller *User, archive *zip.Reader, dryRun bool) (*ImportJob, error) {
+	job := &ImportJob{Status: ImportRunning, DryRun: dryRun}
+	state := &importState{usersById: map[string]*User{}, teamsById: map[string]*Team{}, channelsById: map[string]*Channel{}}
+
+	if err := imp.importAll(caller, archive, job, state); err != nil {
+		job.Status = ImportFailed
+		job.Error = err.Error()
+		if !dryRun {
+			imp.rollback(caller, state)
+		}
+		return job, err
+	}
+
+	job.Status = ImportCompleted
+	return job, nil
+}
+
+func (imp *Importer) importAll(caller *User, archive *zip.Reader, job *ImportJob, state *importState) error {
+	if err := imp.importUsers(caller, archive, job, state.usersById); err != nil {
+		return err
+	}
+	if err := imp.importTeams(caller, archive, job, state.teamsById); err != nil {
+		return err
+	}
+	if err := imp.importChannels(caller, archive, job, state.teamsById, state.channelsById); err != nil {
+		return err
+	}
+	return imp.importMessages(caller, archive, job, state.usersById, state.channelsById, &state.messages)
+}
+
+/**
+ * Best-effort compensating deletes for everything state tracked, in reverse
+ * dependency order.  A rollback failure is logged rather than returned --
+ * the caller already has the original failure to report, and a half failed
+ * rollback is still strictly better than not trying.
+ */
+func (imp *Importer) rollback(caller *User, state *importState) {
+	// Users are intentionally not rolled back: IUserService has no
+	// DeleteUser (see services.IUserService), so an already-created user
+	// from a failed import is left in place.
+	for _, message := range state.messages {
+		if err := imp.Messages.DeleteMessage(caller, message); err != nil {
+			log.Println("import rollback: failed to delete message", message.Id, ":", err)
+		}
+	}
+	for _, channel := range state.channelsById {
+		if err := imp.Channels.DeleteChannel(caller, channel); err != nil {
+			log.Println("import rollback: failed to delete channel", channel.Id, ":", err)
+		}
+	}
+	for _, team := range state.teamsById {
+		if err := imp.Teams.DeleteTeam(caller, team); err != nil {
+			log.Println("import rollback: failed to delete team", team.Id, ":", err)
+		}
+	}
+}
+
+func readJSONFile(archive *zip.Reader, name string, dest interface{}) error {
+	f, err := archive.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewDecoder(f).Decode(dest)
+}
+
+func (imp *Importer) importUsers(caller *User, archive *zip.Reader, job *ImportJob, usersById map[string]*User) error {
+	var records []userRecord
+	if err := readJSONFile(archive, UsersFile, &records); err != nil {
+		return fmt.Errorf("reading %s: %w", UsersFile, err)
+	}
+	for _, r := range records {
+		if job.DryRun {
+			job.Diffs = append(job.Diffs, &ImportDiff{Kind: "user", Id: r.Id, Action: "create", Message: r.Username})
+			continue
+		}
+		user, err := imp.Users.CreateUser(caller, r.Id, r.Username)
+		if err != nil {
+			return fmt.Errorf("creating user %s: %w", r.Id, err)
+		}
+		usersById[r.Id] = user
+		job.Diffs = append(job.Diffs, &ImportDiff{Kind: "user", Id: r.Id, Action: "create"})
+	}
+	return nil
+}
+
+func (imp *Importer) importTeams(caller *User, archive *zip.Reader, job *ImportJob, teamsById map[string]*Team) error {
+	var records []teamRecord
+	if err := readJSONFile(archive, TeamsFile, &records); err != nil {
+		return fmt.Errorf("reading %s: %w", TeamsFile, err)
+	}
+	for _, r := range records {
+		if job.DryRun {
+			job.Diffs = append(job.Diffs, &ImportDiff{Kind: "team", Id: r.Id, Action: "create", Message: r.Name})
+			continue
+		}
+		team, err := imp.Teams.CreateTeam(caller, r.Id, r.Org, r.Name)
+		if err != nil {
+			return fmt.Errorf("creating team %s: %w", r.Id, err)
+		}
+		teamsById[r.Id] = team
+		job.Diffs = append(job.Diffs, &ImportDiff{Kind: "team", Id: r.Id, Action: "create"})
+	}
+	return nil
+}
+
+func (imp *Importer) importChannels(caller *User, archive *zip.Reader, job *ImportJob, teamsById map[string]*Team, channelsById map[string]*Channel) error {
+	var records []channelRecord
+	if err := readJSONFile(archive, ChannelsFile, &records); err != nil {
+		return fmt.Errorf("reading %s: %w", ChannelsFile, err)
+	}
+	for _, r := range records {
+		if job.DryRun {
+			job.Diffs = append(job.Diffs, &ImportDiff{Kind: "channel", Id: r.Id, Action: "create", Message: r.Name})
+			continue
+		}
+		team, ok := teamsById[r.TeamId]
+		if !ok {
+			return fmt.Errorf("creating channel %s: team %s not found (was it in %s?)", r.Id, r.TeamId, TeamsFile)
+		}
+		channel := &Channel{Id: r.Id, Name: r.Name, Team: team}
+		if err := imp.Channels.SaveChannel(caller, channel, true); err != nil {
+			return fmt.Errorf("creating channel %s: %w", r.Id, err)
+		}
+		channelsById[r.Id] = channel
+		job.Diffs = append(job.Diffs, &ImportDiff{Kind: "channel", Id: r.Id, Action: "create"})
+	}
+	return nil
+}
+
+func (imp *Importer) importMessages(caller *User, archive *zip.Reader, job *ImportJob, usersById map[string]*User, channelsById map[string]*Channel, created *[]*Message) error {
+	for _, f := range archive.File {
+		if len(f.Name) <= len(MessagesDir) || f.Name[:len(MessagesDir)] != MessagesDir {
+			continue
+		}
+		channelId := strings.TrimSuffix(f.Name[len(MessagesDir):], ".jsonl")
+		var channel *Channel
+		if job.DryRun {
+			channel = &Channel{Id: channelId}
+		} else {
+			var ok bool
+			channel, ok = channelsById[channelId]
+			if !ok {
+				return fmt.Errorf("importing %s: channel %s not found (was it in %s?)", f.Name, channelId, ChannelsFile)
+			}
+		}
+		if err := imp.importMessagesFile(caller, f, job, channel, usersById, created); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (imp *Importer) importMessagesFile(caller *User, f *zip.File, job *ImportJob, channel *Channel, usersById map[string]*User, created *[]*Message) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		var r messageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return fmt.Errorf("parsing %s: %w", f.Name, err)
+		}
+		if job.DryRun {
+			job.Diffs = append(job.Diffs, &ImportDiff{Kind: "message", Id: r.Id, Action: "create"})
+			continue
+		}
+		sender, ok := usersById[r.SenderId]
+		if !ok {
+			return fmt.Errorf("creating message %s: sender %s not found (was it in %s?)", r.Id, r.SenderId, UsersFile)
+		}
+		message := &Message{Id: r.Id, Channel: channel, Sender: sender, Body: r.Body}
+		if err := imp.Messages.CreateMessage(caller, message); err != nil {
+			return fmt.Errorf("creating message %s: %w", r.Id, err)
+		}
+		*created = append(*created, message)
+		job.Diffs = append(job.Diffs, &ImportDiff{Kind: "message", Id: r.Id, Action: "create"})
+	}
+	return scanner.Err()
+}
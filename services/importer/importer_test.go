@@ -0,0 +1,259 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	. "github.com/panyam/backbone/models"
+	. "github.com/panyam/relay/services/importer/core"
+	. "github.com/panyam/relay/services/presence/core"
+)
+
+// Minimal in-memory fakes satisfying the service interfaces, just enough to
+// drive Importer without a real backend.
+
+type fakeUsers struct {
+	byId map[string]*User
+}
+
+func newFakeUsers() *fakeUsers { return &fakeUsers{byId: map[string]*User{}} }
+
+func (f *fakeUsers) GetUserById(caller *User, id string) (*User, error) { return f.byId[id], nil }
+func (f *fakeUsers) GetUser(caller *User, username string) (*User, error) {
+	for _, u := range f.byId {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+func (f *fakeUsers) SaveUser(caller *User, user *User) error {
+	f.byId[user.Id.(string)] = user
+	return nil
+}
+func (f *fakeUsers) CreateUser(caller *User, id string, username string) (*User, error) {
+	user := &User{Id: id, Username: username}
+	f.byId[id] = user
+	return user, nil
+}
+func (f *fakeUsers) SetStatus(caller *User, user *User, status Status) error { return nil }
+func (f *fakeUsers) SetAutoResponder(caller *User, user *User, active bool, message string) error {
+	return nil
+}
+func (f *fakeUsers) GetStatus(caller *User, user *User) (*UserPresence, error) { return nil, nil }
+
+type fakeTeams struct {
+	byId    map[string]*Team
+	deleted map[string]bool
+}
+
+func newFakeTeams() *fakeTeams {
+	return &fakeTeams{byId: map[string]*Team{}, deleted: map[string]bool{}}
+}
+
+func (f *fakeTeams) CreateTeam(caller *User, id string, org string, name string) (*Team, error) {
+	team := &Team{Id: id, Name: name}
+	f.byId[id] = team
+	return team, nil
+}
+func (f *fakeTeams) GetTeamsInOrg(caller *User, org string, offset int, count int) ([]*Team, error) {
+	return nil, nil
+}
+func (f *fakeTeams) GetTeamByName(caller *User, org string, name string) (*Team, error) {
+	return nil, nil
+}
+func (f *fakeTeams) DeleteTeam(caller *User, team *Team) error {
+	f.deleted[team.Id.(string)] = true
+	return nil
+}
+func (f *fakeTeams) JoinTeam(caller *User, team *Team, user *User) error    { return nil }
+func (f *fakeTeams) TeamContains(caller *User, team *Team, user *User) bool { return false }
+func (f *fakeTeams) LeaveTeam(caller *User, team *Team, user *User) error   { return nil }
+
+type fakeChannels struct {
+	byId    map[string]*Channel
+	deleted map[string]bool
+}
+
+func newFakeChannels() *fakeChannels {
+	return &fakeChannels{byId: map[string]*Channel{}, deleted: map[string]bool{}}
+}
+
+func (f *fakeChannels) SaveChannel(caller *User, channel *Channel, override bool) error {
+	f.byId[channel.Id.(string)] = channel
+	return nil
+}
+func (f *fakeChannels) GetChannelById(caller *User, id string) (*Channel, error) {
+	return f.byId[id], nil
+}
+func (f *fakeChannels) DeleteChannel(caller *User, channel *Channel) error {
+	f.deleted[channel.Id.(string)] = true
+	return nil
+}
+func (f *fakeChannels) ListChannels(caller *User, user *User, team *Team) ([]*Channel, error) {
+	return nil, nil
+}
+func (f *fakeChannels) JoinChannel(caller *User, channel *Channel, user *User) error  { return nil }
+func (f *fakeChannels) LeaveChannel(caller *User, channel *Channel, user *User) error { return nil }
+
+type fakeMessages struct {
+	created []*Message
+	failAt  int // fail the (failAt+1)'th CreateMessage call, 0 disables
+	deleted []string
+}
+
+func newFakeMessages() *fakeMessages { return &fakeMessages{} }
+
+func (f *fakeMessages) GetMessages(caller *User, channel *Channel, user *User, offset int, count int) ([]*Message, error) {
+	return nil, nil
+}
+func (f *fakeMessages) CreateMessage(caller *User, message *Message) error {
+	if f.failAt != 0 && len(f.created)+1 == f.failAt {
+		return errTestCreateMessage
+	}
+	f.created = append(f.created, message)
+	return nil
+}
+func (f *fakeMessages) DeleteMessage(caller *User, message *Message) error {
+	f.deleted = append(f.deleted, message.Id)
+	return nil
+}
+
+var errTestCreateMessage = fmtError("synthetic CreateMessage failure")
+
+type fmtError string
+
+func (e fmtError) Error() string { return string(e) }
+
+func buildArchive(t *testing.T, files map[string]string) *zip.Reader {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("creating %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("closing archive: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("reopening archive: %v", err)
+	}
+	return zr
+}
+
+const sampleUsers = `[{"id":"u1","username":"alice"},{"id":"u2","username":"bob"}]`
+const sampleTeams = `[{"id":"t1","org":"acme","name":"Acme"}]`
+const sampleChannels = `[{"id":"c1","team_id":"t1","name":"general"}]`
+const sampleMessages = `{"id":"m1","channel_id":"c1","sender_id":"u1","body":"hello"}
+{"id":"m2","channel_id":"c1","sender_id":"u2","body":"hi back"}
+`
+
+func newTestImporter() (*Importer, *fakeUsers, *fakeTeams, *fakeChannels, *fakeMessages) {
+	users := newFakeUsers()
+	teams := newFakeTeams()
+	channels := newFakeChannels()
+	messages := newFakeMessages()
+	return NewImporter(users, teams, channels, messages), users, teams, channels, messages
+}
+
+func TestImportDryRunWritesNothing(t *testing.T) {
+	archive := buildArchive(t, map[string]string{
+		UsersFile:           sampleUsers,
+		TeamsFile:           sampleTeams,
+		ChannelsFile:        sampleChannels,
+		"messages/c1.jsonl": sampleMessages,
+	})
+	caller := &User{Id: "admin"}
+	imp, users, teams, channels, messages := newTestImporter()
+
+	job, err := imp.Import(caller, archive, true)
+	if err != nil {
+		t.Fatalf("Import returned error: %v", err)
+	}
+	if job.Status != ImportCompleted {
+		t.Fatalf("expected ImportCompleted, got %v", job.Status)
+	}
+	if len(users.byId) != 0 || len(teams.byId) != 0 || len(channels.byId) != 0 || len(messages.created) != 0 {
+		t.Fatalf("dry run must not write: users=%d teams=%d channels=%d messages=%d",
+			len(users.byId), len(teams.byId), len(channels.byId), len(messages.created))
+	}
+	if len(job.Diffs) != 6 { // 2 users + 1 team + 1 channel + 2 messages
+		t.Fatalf("expected 6 diffs, got %d", len(job.Diffs))
+	}
+}
+
+func TestImportWiresChannelTeamAndMessageSenderChannel(t *testing.T) {
+	archive := buildArchive(t, map[string]string{
+		UsersFile:           sampleUsers,
+		TeamsFile:           sampleTeams,
+		ChannelsFile:        sampleChannels,
+		"messages/c1.jsonl": sampleMessages,
+	})
+	caller := &User{Id: "admin"}
+	imp, _, teams, channels, messages := newTestImporter()
+
+	job, err := imp.Import(caller, archive, false)
+	if err != nil {
+		t.Fatalf("Import returned error: %v", err)
+	}
+	if job.Status != ImportCompleted {
+		t.Fatalf("expected ImportCompleted, got %v: %s", job.Status, job.Error)
+	}
+
+	channel := channels.byId["c1"]
+	if channel == nil {
+		t.Fatalf("channel c1 was not created")
+	}
+	if channel.Team != teams.byId["t1"] {
+		t.Fatalf("channel c1.Team = %v, want team t1", channel.Team)
+	}
+
+	if len(messages.created) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages.created))
+	}
+	for _, m := range messages.created {
+		if m.Channel != channel {
+			t.Fatalf("message %s.Channel = %v, want channel c1", m.Id, m.Channel)
+		}
+		if m.Sender == nil || m.Sender.Id == "" {
+			t.Fatalf("message %s.Sender not set", m.Id)
+		}
+	}
+}
+
+func TestImportRollsBackOnFailure(t *testing.T) {
+	archive := buildArchive(t, map[string]string{
+		UsersFile:           sampleUsers,
+		TeamsFile:           sampleTeams,
+		ChannelsFile:        sampleChannels,
+		"messages/c1.jsonl": sampleMessages,
+	})
+	caller := &User{Id: "admin"}
+	imp, _, teams, channels, messages := newTestImporter()
+	messages.failAt = 2 // fail on the second message
+
+	job, err := imp.Import(caller, archive, false)
+	if err == nil {
+		t.Fatalf("expected Import to fail")
+	}
+	if job.Status != ImportFailed {
+		t.Fatalf("expected ImportFailed, got %v", job.Status)
+	}
+	if len(messages.deleted) != 1 {
+		t.Fatalf("expected the 1 successfully created message to be rolled back, got %d", len(messages.deleted))
+	}
+	if !channels.deleted["c1"] {
+		t.Fatalf("expected channel c1 to be rolled back")
+	}
+	if !teams.deleted["t1"] {
+		t.Fatalf("expected team t1 to be rolled back")
+	}
+}
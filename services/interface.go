@@ -2,6 +2,10 @@ package services
 
 import (
 	. "github.com/panyam/backbone/models"
+	. "github.com/panyam/relay/services/authz/core"
+	. "github.com/panyam/relay/services/commands/core"
+	. "github.com/panyam/relay/services/hub/core"
+	. "github.com/panyam/relay/services/presence/core"
 )
 
 type IIDService interface {
@@ -17,20 +21,21 @@ type IIDService interface {
 }
 
 /**
- * Base service operations.  These dont care about authorization for now and
- * assume the user is authorized.  Authn (and possible Authz) have to be taken
- * care of seperately.
+ * Base service operations.  Every method now takes the caller as its first
+ * argument so the implementation can route through IAuthzService before
+ * doing any work -- see IAuthzService for the relation model being checked
+ * against.
  */
 type IUserService interface {
 	/**
 	 * Get user info by ID
 	 */
-	GetUserById(id string) (*User, error)
+	GetUserById(caller *User, id string) (*User, error)
 
 	/**
 	 * Get a user by username.
 	 */
-	GetUser(username string) (*User, error)
+	GetUser(caller *User, username string) (*User, error)
 
 	/**
 	 * Saves a user details.
@@ -38,12 +43,12 @@ type IUserService interface {
 	 * If the username or user id already exist and are not the same
 	 * object then an error is thrown.
 	 */
-	SaveUser(user *User) error
+	SaveUser(caller *User, user *User) error
 
 	/**
 	 * Deletes a user from the sytem
 	 */
-	// DeleteUser(user *User) error
+	// DeleteUser(caller *User, user *User) error
 
 	/**
 	 * Create a user with the given id and username.
@@ -53,7 +58,26 @@ type IUserService interface {
 	 * A valid User object on return WILL have an ID if the backend can
 	 * auto generate IDs
 	 */
-	CreateUser(id string, username string) (*User, error)
+	CreateUser(caller *User, id string, username string) (*User, error)
+
+	/**
+	 * Sets a user's live status (online/away/dnd/offline).
+	 */
+	SetStatus(caller *User, user *User, status Status) error
+
+	/**
+	 * Enables or disables the away-message auto-responder for user, and sets
+	 * the message it replies with while active.  While active,
+	 * IMessageService.CreateMessage synthesizes a reply from user for DMs
+	 * addressed to them, throttled to at most one auto-reply per sender per
+	 * 24h.
+	 */
+	SetAutoResponder(caller *User, user *User, active bool, message string) error
+
+	/**
+	 * Retrieves a user's current status and auto-responder configuration.
+	 */
+	GetStatus(caller *User, user *User) (*UserPresence, error)
 }
 
 type ITeamService interface {
@@ -64,37 +88,40 @@ type ITeamService interface {
 	 * A valid Team object on return WILL have an ID if the backend can
 	 * auto generate IDs
 	 */
-	CreateTeam(id string, org string, name string) (*Team, error)
+	CreateTeam(caller *User, id string, org string, name string) (*Team, error)
 
 	/**
-	 * Retrieve teams in a org
+	 * Retrieve teams in a org.  Implementations call
+	 * IAuthzService.ListAllObjects(caller, PermissionView, ObjectTypeTeam) and
+	 * filter the org's teams down to that set rather than returning every
+	 * team in the org.
 	 */
-	GetTeamsInOrg(org string, offset int, count int) ([]*Team, error)
+	GetTeamsInOrg(caller *User, org string, offset int, count int) ([]*Team, error)
 
 	/**
 	 * Retrieve a team by Name.
 	 */
-	GetTeamByName(org string, name string) (*Team, error)
+	GetTeamByName(caller *User, org string, name string) (*Team, error)
 
 	/**
-	 * Delete a team.
+	 * Delete a team.  Requires caller to hold RoleAdmin on the team.
 	 */
-	DeleteTeam(team *Team) error
+	DeleteTeam(caller *User, team *Team) error
 
 	/**
 	 * Lets a user to join a team (if allowed)
 	 */
-	JoinTeam(team *Team, user *User) error
+	JoinTeam(caller *User, team *Team, user *User) error
 
 	/**
 	 * Tells if a user belongs to a team.
 	 */
-	TeamContains(team *Team, user *User) bool
+	TeamContains(caller *User, team *Team, user *User) bool
 
 	/**
 	 * Lets a user leave a team or be kicked out.
 	 */
-	LeaveTeam(team *Team, user *User) error
+	LeaveTeam(caller *User, team *Team, user *User) error
 }
 
 type IChannelService interface {
@@ -108,39 +135,41 @@ type IChannelService interface {
 	 * 		if it does not already exist and returns a ChannelExists error if an
 	 * 		existing channel with the same ID exists.
 	 */
-	SaveChannel(channel *Channel, override bool) error
+	SaveChannel(caller *User, channel *Channel, override bool) error
 
 	/**
 	 * Get channel by Id
 	 */
-	GetChannelById(id string) (*Channel, error)
+	GetChannelById(caller *User, id string) (*Channel, error)
 
 	/**
 	 * Delete a channel.
 	 */
-	DeleteChannel(channel *Channel) error
+	DeleteChannel(caller *User, channel *Channel) error
 
 	/**
 	 * Returns the channels the user belongs to in a given team.
 	 */
-	ListChannels(user *User, team *Team) ([]*Channel, error)
+	ListChannels(caller *User, user *User, team *Team) ([]*Channel, error)
 
 	/**
-	 * Lets a user to join a channel (if allowed)
+	 * Lets a user to join a channel.  Implementations call
+	 * IAuthzService.Authorize(caller, PermissionJoin, channel) before
+	 * JoinChannel is allowed to proceed.
 	 */
-	JoinChannel(channel *Channel, user *User) error
+	JoinChannel(caller *User, channel *Channel, user *User) error
 
 	/**
 	 * Lets a user leave a channel or be kicked out.
 	 */
-	LeaveChannel(channel *Channel, user *User) error
+	LeaveChannel(caller *User, channel *Channel, user *User) error
 }
 
 type IMessageService interface {
 	/**
 	 * Get the messages in a channel for a particular user.
 	 */
-	GetMessages(channel *Channel, user *User, offset int, count int) ([]*Message, error)
+	GetMessages(caller *User, channel *Channel, user *User, offset int, count int) ([]*Message, error)
 
 	/**
 	 * Creates a message to particular recipients in this channel.  This is
@@ -150,18 +179,139 @@ type IMessageService interface {
 	 * capable of doing so.
 	 * A valid Message object on return WILL have a non empty ID if the backend can
 	 * auto generate IDs
+	 *
+	 * If the message body starts with "/" it is routed through the
+	 * registered ICommandService handler for its trigger instead of being
+	 * persisted verbatim.  The handler may mutate the message before it is
+	 * saved, replace it with an ephemeral response visible only to the
+	 * invoker, or fully handle it as a side effect (eg /join, /leave) in
+	 * which case no message is persisted at all.
+	 *
+	 * For a DM channel (two participants) where the recipient has an active
+	 * auto-responder, a reply message is synthesized from the recipient and
+	 * persisted the same way any other message is, so it flows through the
+	 * same hub fan-out.  Synthesized replies are throttled to at most one
+	 * per (sender, recipient) pair per 24h, and are never generated for
+	 * system/bot messages or channels with more than two participants.
 	 */
-	CreateMessage(message *Message) error
+	CreateMessage(caller *User, message *Message) error
 
 	/**
 	 * Remove a particular message.
 	 */
-	DeleteMessage(message *Message) error
+	DeleteMessage(caller *User, message *Message) error
 
 	/**
 	 * Saves a message.
 	 * If the message ID is missing (or empty) then a new message is created.
 	 * If message ID is present then the existing message is updated.
 	 */
-	// SaveMessage(message *Message) error
+	// SaveMessage(caller *User, message *Message) error
+}
+
+/**
+ * Centralizes RBAC checks for the other services via a relation model:
+ * subjects (users) hold a Role (owner, admin, member, viewer) over objects
+ * (teams, channels, messages).  An in-memory implementation lives in
+ * services/authz/memory; services/authz.Adapter lets an external policy
+ * engine (eg a Zanzibar-style service) stand in for it instead.
+ */
+type IAuthzService interface {
+	/**
+	 * Reports whether subject holds permission over object.
+	 */
+	Authorize(subject *User, permission Permission, object *Object) (bool, error)
+
+	/**
+	 * Returns the ids of every object of objectType that subject holds
+	 * permission over, eg so GetTeamsInOrg can filter an org's teams down to
+	 * the ones the caller may view.
+	 */
+	ListAllObjects(subject *User, permission Permission, objectType ObjectType) ([]string, error)
+
+	/**
+	 * Grants subject role over object, creating or replacing any existing
+	 * relation tuple for that (subject, object) pair.
+	 */
+	AssignRole(subject *User, object *Object, role Role) error
+
+	/**
+	 * Revokes whatever role subject holds over object.  A no-op if no
+	 * relation tuple exists for the pair.
+	 */
+	UnassignRole(subject *User, object *Object) error
+}
+
+/**
+ * Manages the slash-command pipeline: registration of handlers by trigger
+ * (eg "/me", "/msg", "/join") along with their auto-complete metadata, and
+ * dispatching a parsed command to its handler.  IMessageService.CreateMessage
+ * is the sole caller of Execute today, but the registry itself is generic
+ * enough to be driven directly (eg for the REST /commands/ endpoints).
+ */
+type ICommandService interface {
+	/**
+	 * Registers a handler for meta.Trigger.  Returns an error if the trigger
+	 * is already registered.
+	 */
+	RegisterCommand(meta *CommandMetadata, handler CommandHandler) error
+
+	/**
+	 * Removes a previously registered handler.  A no-op, returning a nil
+	 * error, if the trigger was never registered.
+	 */
+	UnregisterCommand(trigger string) error
+
+	/**
+	 * Looks up the metadata for a single trigger, eg to answer a specific
+	 * auto-complete query.
+	 */
+	GetCommand(trigger string) (*CommandMetadata, error)
+
+	/**
+	 * Lists the commands available for the given scope, used to drive
+	 * client-side auto-complete.
+	 */
+	ListCommands(scope CommandScope) ([]*CommandMetadata, error)
+
+	/**
+	 * Parses ctx.Message.Body for its trigger and dispatches to the
+	 * registered handler.  Returns an error if the body does not start with
+	 * "/" or the trigger is not registered.
+	 */
+	Execute(ctx *CommandContext) (*CommandResponse, error)
+}
+
+/**
+ * Fans real-time events (message_created, message_deleted,
+ * user_joined_channel, user_left_channel, registration_confirmed, typing)
+ * out to subscribed connections over the /ws endpoint.  IMessageService and
+ * IChannelService publish into the hub rather than writing to connections
+ * directly, so persistence and broadcast stay decoupled: a caller that only
+ * needs storage behaviour can depend on those interfaces without ever
+ * touching IHubService.
+ */
+type IHubService interface {
+	/**
+	 * Registers conn to receive events visible to user, bootstrapping the
+	 * subscription set from the channels ListChannels(user, team) returns.
+	 */
+	Subscribe(user *User, team *Team, conn Connection) error
+
+	/**
+	 * Removes conn from the hub, eg on disconnect.
+	 */
+	Unsubscribe(user *User, conn Connection) error
+
+	/**
+	 * Publishes event to every connection subscribed to its Team/Channel
+	 * that CanSee reports true for.
+	 */
+	Publish(event *Event) error
+
+	/**
+	 * Answers "may user see events for channel" using the per-user access
+	 * cache, without hitting storage on every Publish call.
+	 */
+	CanSee(user *User, channel *Channel) bool
 }
@@ -9,11 +9,12 @@ import (
 
 func (s *TestSuite) TestCreateTeam(c *C) {
 	svc := s.serviceGroup.TeamService
-	team, err := svc.CreateTeam(0, "group", "test")
+	caller := &User{Id: "admin"}
+	team, err := svc.CreateTeam(caller, "", "group", "test")
 	c.Assert(err, IsNil)
 	c.Assert(team, Not(IsNil))
 	c.Assert(team.Name, Equals, "test")
-	team, err = svc.GetTeamByName("group", "test")
+	team, err = svc.GetTeamByName(caller, "group", "test")
 	c.Assert(err, IsNil)
 	c.Assert(team, Not(IsNil))
 	c.Assert(team.Name, Equals, "test")
@@ -21,24 +22,26 @@ func (s *TestSuite) TestCreateTeam(c *C) {
 
 func (s *TestSuite) TestCreateTeamExistsByName(c *C) {
 	svc := s.serviceGroup.TeamService
-	_, err := svc.CreateTeam(1, "group", "test")
-	team, err := svc.CreateTeam(1, "group2", "test2")
+	caller := &User{Id: "admin"}
+	_, err := svc.CreateTeam(caller, "1", "group", "test")
+	team, err := svc.CreateTeam(caller, "1", "group2", "test2")
 	c.Assert(err, Not(IsNil))
 	c.Assert(team, IsNil)
 }
 
 func (s *TestSuite) TestDeleteTeam(c *C) {
 	svc := s.serviceGroup.TeamService
-	team, err := svc.CreateTeam(0, "group", "test")
+	caller := &User{Id: "admin"}
+	team, err := svc.CreateTeam(caller, "", "group", "test")
 	c.Assert(err, IsNil)
 	c.Assert(team, Not(IsNil))
-	c.Assert(team.Id, Not(Equals), 0)
+	c.Assert(team.Id, Not(Equals), "")
 
 	log.Println("TeamID: ", team.Id)
-	err = svc.DeleteTeam(team)
+	err = svc.DeleteTeam(caller, team)
 	c.Assert(err, IsNil)
 
-	team, err = svc.GetTeamByName("group", "test")
+	team, err = svc.GetTeamByName(caller, "group", "test")
 	c.Assert(team, IsNil)
 	c.Assert(err, Not(IsNil))
 }
@@ -0,0 +1,30 @@
+package core
+
+/**
+ * A user's live availability, as shown to other users.
+ */
+type Status string
+
+const (
+	StatusOnline  Status = "online"
+	StatusAway    Status = "away"
+	StatusDnd     Status = "dnd"
+	StatusOffline Status = "offline"
+)
+
+/**
+ * The away/auto-responder state for a single user.  Kept as a side table
+ * (rather than fields directly on the backbone/models.User we don't own)
+ * and looked up by user id.
+ */
+type UserPresence struct {
+	UserId string
+	Status Status
+
+	// Whether CreateMessage should synthesize an auto-reply on this user's
+	// behalf for DMs addressed to them.
+	AutoResponderActive bool
+
+	// The body of the synthesized auto-reply.
+	AutoResponderMessage string
+}
@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/panyam/backbone/models"
+	. "github.com/panyam/relay/services/presence/core"
+)
+
+// How long a synthesized auto-reply from one recipient to a given sender is
+// suppressed for after the first one, per services.IUserService.SetAutoResponder.
+const AutoReplyThrottle = 24 * time.Hour
+
+type replyKey struct {
+	sender    interface{}
+	recipient interface{}
+}
+
+/**
+ * An in-memory presence/auto-responder engine: a userId -> UserPresence
+ * store plus the throttled auto-reply synthesizer IMessageService.CreateMessage
+ * is expected to call for DMs.  Kept standalone (rather than a full
+ * IUserService implementation) since user CRUD lives elsewhere; this is the
+ * injectable component analogous to services/authz/memory that owns just
+ * the presence/auto-responder behaviour.
+ */
+type PresenceService struct {
+	mutex sync.RWMutex
+
+	byUser      map[interface{}]*UserPresence
+	lastReplyAt map[replyKey]time.Time
+
+	// Overridable for tests; defaults to time.Now.
+	Now func() time.Time
+}
+
+func NewPresenceService() *PresenceService {
+	return &PresenceService{
+		byUser:      map[interface{}]*UserPresence{},
+		lastReplyAt: map[replyKey]time.Time{},
+		Now:         time.Now,
+	}
+}
+
+func (svc *PresenceService) presenceLocked(userId interface{}) *UserPresence {
+	presence, ok := svc.byUser[userId]
+	if !ok {
+		presence = &UserPresence{UserId: stringifyId(userId), Status: StatusOffline}
+		svc.byUser[userId] = presence
+	}
+	return presence
+}
+
+// stringifyId renders a backbone model id (typed interface{} since the
+// backing store may hand out string or numeric ids) for UserPresence.UserId,
+// the same way services/authz.userId does for subjects passed to a
+// PolicyEngine.
+func stringifyId(id interface{}) string {
+	switch v := id.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func (svc *PresenceService) SetStatus(user *User, status Status) error {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+	svc.presenceLocked(user.Id).Status = status
+	return nil
+}
+
+func (svc *PresenceService) SetAutoResponder(user *User, active bool, message string) error {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+	presence := svc.presenceLocked(user.Id)
+	presence.AutoResponderActive = active
+	presence.AutoResponderMessage = message
+	return nil
+}
+
+func (svc *PresenceService) GetStatus(user *User) (*UserPresence, error) {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+	presence, ok := svc.byUser[user.Id]
+	if !ok {
+		return &UserPresence{UserId: stringifyId(user.Id), Status: StatusOffline}, nil
+	}
+	out := *presence
+	return &out, nil
+}
+
+/**
+ * SynthesizeReply decides whether CreateMessage should synthesize an
+ * auto-reply from sender's perspective for a message it is about to persist
+ * into channel, and returns it if so.  Returns false for group channels
+ * (more than two participants), system/bot messages, a recipient with no
+ * active auto-responder, or a (sender, recipient) pair that already got one
+ * within AutoReplyThrottle.
+ */
+func (svc *PresenceService) SynthesizeReply(channel *Channel, participants []*User, sender *User, isSystemOrBot bool) (*Message, bool) {
+	if isSystemOrBot || len(participants) != 2 {
+		return nil, false
+	}
+	var recipient *User
+	for _, p := range participants {
+		if p.Id != sender.Id {
+			recipient = p
+		}
+	}
+	if recipient == nil {
+		return nil, false
+	}
+
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+
+	presence, ok := svc.byUser[recipient.Id]
+	if !ok || !presence.AutoResponderActive {
+		return nil, false
+	}
+
+	key := replyKey{sender: sender.Id, recipient: recipient.Id}
+	now := svc.Now()
+	if last, ok := svc.lastReplyAt[key]; ok && now.Sub(last) < AutoReplyThrottle {
+		return nil, false
+	}
+	svc.lastReplyAt[key] = now
+
+	return &Message{Channel: channel, Sender: recipient, Body: presence.AutoResponderMessage}, true
+}
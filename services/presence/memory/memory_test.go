@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/panyam/backbone/models"
+	. "github.com/panyam/relay/services/presence/core"
+)
+
+func newTestService(now time.Time) (*PresenceService, *time.Time) {
+	svc := NewPresenceService()
+	clock := now
+	svc.Now = func() time.Time { return clock }
+	return svc, &clock
+}
+
+func TestSynthesizeReplyWhenAutoResponderActive(t *testing.T) {
+	alice := &User{Id: "alice"}
+	bob := &User{Id: "bob"}
+	channel := &Channel{Id: "dm-alice-bob"}
+	svc, _ := newTestService(time.Unix(0, 0))
+	svc.SetAutoResponder(bob, true, "I'm away")
+
+	reply, ok := svc.SynthesizeReply(channel, []*User{alice, bob}, alice, false)
+	if !ok {
+		t.Fatalf("expected an auto-reply to be synthesized")
+	}
+	if reply.Sender != bob || reply.Body != "I'm away" || reply.Channel != channel {
+		t.Fatalf("unexpected reply: %+v", reply)
+	}
+}
+
+func TestSynthesizeReplySkipsWithoutActiveAutoResponder(t *testing.T) {
+	alice := &User{Id: "alice"}
+	bob := &User{Id: "bob"}
+	channel := &Channel{Id: "dm-alice-bob"}
+	svc, _ := newTestService(time.Unix(0, 0))
+
+	if _, ok := svc.SynthesizeReply(channel, []*User{alice, bob}, alice, false); ok {
+		t.Fatalf("expected no auto-reply when bob has no auto-responder configured")
+	}
+}
+
+func TestSynthesizeReplySkipsGroupChannels(t *testing.T) {
+	alice := &User{Id: "alice"}
+	bob := &User{Id: "bob"}
+	carol := &User{Id: "carol"}
+	channel := &Channel{Id: "group"}
+	svc, _ := newTestService(time.Unix(0, 0))
+	svc.SetAutoResponder(bob, true, "I'm away")
+
+	if _, ok := svc.SynthesizeReply(channel, []*User{alice, bob, carol}, alice, false); ok {
+		t.Fatalf("expected no auto-reply for a channel with more than two participants")
+	}
+}
+
+func TestSynthesizeReplySkipsSystemAndBotMessages(t *testing.T) {
+	alice := &User{Id: "alice"}
+	bob := &User{Id: "bob"}
+	channel := &Channel{Id: "dm-alice-bob"}
+	svc, _ := newTestService(time.Unix(0, 0))
+	svc.SetAutoResponder(bob, true, "I'm away")
+
+	if _, ok := svc.SynthesizeReply(channel, []*User{alice, bob}, alice, true); ok {
+		t.Fatalf("expected no auto-reply for a system/bot message")
+	}
+}
+
+func TestSynthesizeReplyThrottledPerSenderRecipientPair(t *testing.T) {
+	alice := &User{Id: "alice"}
+	bob := &User{Id: "bob"}
+	carol := &User{Id: "carol"}
+	channel := &Channel{Id: "dm"}
+	svc, clock := newTestService(time.Unix(0, 0))
+	svc.SetAutoResponder(bob, true, "I'm away")
+
+	if _, ok := svc.SynthesizeReply(channel, []*User{alice, bob}, alice, false); !ok {
+		t.Fatalf("expected the first auto-reply to be synthesized")
+	}
+	if _, ok := svc.SynthesizeReply(channel, []*User{alice, bob}, alice, false); ok {
+		t.Fatalf("expected a second auto-reply from alice to bob within 24h to be throttled")
+	}
+
+	// A different sender isn't throttled by alice's reply.
+	if _, ok := svc.SynthesizeReply(channel, []*User{carol, bob}, carol, false); !ok {
+		t.Fatalf("expected carol's first auto-reply to be synthesized")
+	}
+
+	// Past the throttle window, alice gets another auto-reply.
+	*clock = clock.Add(AutoReplyThrottle + time.Second)
+	if _, ok := svc.SynthesizeReply(channel, []*User{alice, bob}, alice, false); !ok {
+		t.Fatalf("expected a new auto-reply once AutoReplyThrottle has elapsed")
+	}
+}
+
+func TestGetStatusDefaultsToOffline(t *testing.T) {
+	alice := &User{Id: "alice"}
+	svc := NewPresenceService()
+
+	presence, err := svc.GetStatus(alice)
+	if err != nil {
+		t.Fatalf("GetStatus returned error: %v", err)
+	}
+	if presence.Status != StatusOffline {
+		t.Fatalf("expected default status %v, got %v", StatusOffline, presence.Status)
+	}
+}
+
+func TestSetStatusPersists(t *testing.T) {
+	alice := &User{Id: "alice"}
+	svc := NewPresenceService()
+
+	if err := svc.SetStatus(alice, StatusAway); err != nil {
+		t.Fatalf("SetStatus returned error: %v", err)
+	}
+	presence, err := svc.GetStatus(alice)
+	if err != nil {
+		t.Fatalf("GetStatus returned error: %v", err)
+	}
+	if presence.Status != StatusAway {
+		t.Fatalf("expected status %v, got %v", StatusAway, presence.Status)
+	}
+}